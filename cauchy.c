@@ -1,3 +1,6 @@
+//go:build linux && !purego
+// +build linux,!purego
+
 /* cauchy.c
  * James S. Plank
 
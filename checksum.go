@@ -0,0 +1,100 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"sync"
+)
+
+// CRC32C is the name of the default checksum hash, CRC32 with the
+// Castagnoli polynomial.
+const CRC32C = "crc32c"
+
+// SHA256 is the name of the SHA-256 checksum hash.
+const SHA256 = "sha256"
+
+var (
+	hashesMu sync.RWMutex
+	hashes   = map[string]func() hash.Hash{
+		CRC32C: func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
+		SHA256: sha256.New,
+	}
+)
+
+// RegisterHash makes a hash function available for block checksums
+// under the given name. The name is recorded in stripe manifests, so
+// the same hash has to be registered to decode those stripes.
+func RegisterHash(name string, fn func() hash.Hash) {
+	hashesMu.Lock()
+	defer hashesMu.Unlock()
+	hashes[name] = fn
+}
+
+// newHash returns a new instance of the named hash function.
+func newHash(name string) (hash.Hash, error) {
+	hashesMu.RLock()
+	defer hashesMu.RUnlock()
+	fn, ok := hashes[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownHash, name)
+	}
+	return fn(), nil
+}
+
+// blockHasher computes the checksum of a whole block and of each of its
+// buffers.
+type blockHasher struct {
+	block  hash.Hash
+	buffer hash.Hash
+	sums   BlockChecksums
+}
+
+// newBlockHashers returns a blockHasher for each of n blocks.
+func newBlockHashers(name string, n int) ([]*blockHasher, error) {
+	hashers := make([]*blockHasher, n)
+	for i := range hashers {
+		block, err := newHash(name)
+		if err != nil {
+			return nil, err
+		}
+		buffer, _ := newHash(name)
+		hashers[i] = &blockHasher{block: block, buffer: buffer}
+	}
+	return hashers, nil
+}
+
+// add adds the next buffer of the block.
+func (this *blockHasher) add(buf []byte) {
+	this.block.Write(buf)
+	this.sums.Buffers = append(this.sums.Buffers, sumBuffer(this.buffer, buf))
+}
+
+// checksums returns the checksums of the buffers added so far.
+func (this *blockHasher) checksums() BlockChecksums {
+	this.sums.Checksum = hex.EncodeToString(this.block.Sum(nil))
+	return this.sums
+}
+
+// sumBuffer returns the hex encoded checksum of a single buffer.
+func sumBuffer(h hash.Hash, buf []byte) string {
+	h.Reset()
+	h.Write(buf)
+	return hex.EncodeToString(h.Sum(nil))
+}
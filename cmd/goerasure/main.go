@@ -0,0 +1,259 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Command goerasure encodes, decodes, verifies and repairs stripes of
+// erasure coded blocks.
+//
+// Usage:
+//
+//	goerasure <command> [flags] <stripe>
+//
+// The commands are:
+//
+//	encode  encode the data blocks of a stripe into coding blocks
+//	decode  regenerate the missing blocks of a stripe
+//	verify  check the blocks of a stripe against its manifest
+//	info    print the manifest of a stripe
+//	repair  regenerate the missing and corrupt blocks of a stripe and
+//	        verify the result
+//
+// The code is selected with -technique, which is one of reed_sol_van,
+// reed_sol_r6_op, cauchy_orig, cauchy_good, liberation, blaum_roth and
+// liber8tion, and the -k, -m, -w, -packetsize and -buffersize flags.
+// Decode reads the code from the manifest if no technique is given.
+//
+// goerasure exits with status 1 if a command fails or finds damaged
+// blocks, and with status 2 if it is used incorrectly.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jsgilmore/goerasure"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// command is a subcommand of goerasure.
+type command struct {
+	summary string
+	// code is set if the command takes the flags that select a code.
+	code bool
+	run  func(f *flags, stripe string, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"encode": {"encode the data blocks of a stripe into coding blocks", true, encode},
+	"decode": {"regenerate the missing blocks of a stripe", true, decode},
+	"verify": {"check the blocks of a stripe against its manifest", false, verify},
+	"info":   {"print the manifest of a stripe", false, info},
+	"repair": {"regenerate the missing and corrupt blocks of a stripe and verify the result", false, repair},
+}
+
+// flags holds the flags of a command.
+type flags struct {
+	dir     string
+	naming  string
+	roots   string
+	workers int
+	verbose bool
+	// logger receives the progress of the command if -v is given.
+	logger *log.Logger
+
+	technique  string
+	k, m, w    int
+	packetSize int
+	bufferSize int64
+	hash       string
+}
+
+// register adds the flags of a command to fs.
+func (this *flags) register(fs *flag.FlagSet, code bool) {
+	fs.StringVar(&this.dir, "dir", "", "directory that holds the stripes")
+	fs.StringVar(&this.naming, "naming", "", "template that names the blocks, such as {dir}/{stripe}/{kind}{index}.shard")
+	fs.StringVar(&this.roots, "roots", "", "comma separated roots to place the blocks on, such as the mount points of discs")
+	fs.IntVar(&this.workers, "workers", 1, "number of buffers that are coded at once")
+	fs.BoolVar(&this.verbose, "v", false, "report missing, lost and corrupt blocks on stderr")
+	if !code {
+		return
+	}
+	fs.StringVar(&this.technique, "technique", "", "coding technique: reed_sol_van, reed_sol_r6_op, cauchy_orig, cauchy_good, liberation, blaum_roth or liber8tion")
+	fs.IntVar(&this.k, "k", 0, "number of data blocks")
+	fs.IntVar(&this.m, "m", 0, "number of coding blocks")
+	fs.IntVar(&this.w, "w", 8, "word size")
+	fs.IntVar(&this.packetSize, "packetsize", 0, "packet size of the bitmatrix codes")
+	fs.Int64Var(&this.bufferSize, "buffersize", 0, "number of bytes of every block that are coded at once, 0 for the whole block")
+	fs.StringVar(&this.hash, "hash", goerasure.CRC32C, "hash of the block checksums in the manifest")
+}
+
+// options returns the options that the flags select.
+func (this *flags) options() []goerasure.Option {
+	opts := []goerasure.Option{
+		goerasure.WithStore(goerasure.NewDirStore(this.dir)),
+		goerasure.WithWorkers(this.workers),
+	}
+	if this.hash != "" {
+		opts = append(opts, goerasure.WithHash(this.hash))
+	}
+	if this.naming != "" {
+		opts = append(opts, goerasure.WithNaming(this.naming))
+	}
+	if this.roots != "" {
+		opts = append(opts, goerasure.WithRoots(strings.Split(this.roots, ",")...))
+	}
+	if this.verbose {
+		opts = append(opts, goerasure.WithLogger(this.logger))
+	}
+	return opts
+}
+
+// code returns the code that the flags select.
+func (this *flags) code() (goerasure.Coder, error) {
+	if this.technique == "" {
+		return nil, errors.New("no -technique given")
+	}
+	return goerasure.NewCode(this.technique, this.k, this.m, this.w, this.packetSize, this.bufferSize)
+}
+
+func encode(f *flags, stripe string, stdout io.Writer) error {
+	code, err := f.code()
+	if err != nil {
+		return err
+	}
+	defer code.Close()
+	return goerasure.Encode(stripe, code, f.options()...)
+}
+
+func decode(f *flags, stripe string, stdout io.Writer) error {
+	if f.technique == "" {
+		return goerasure.DecodeStripe(stripe, f.options()...)
+	}
+	code, err := f.code()
+	if err != nil {
+		return err
+	}
+	defer code.Close()
+	return goerasure.Decode(stripe, code, f.options()...)
+}
+
+func verify(f *flags, stripe string, stdout io.Writer) error {
+	damaged, err := goerasure.VerifyStripe(stripe, f.options()...)
+	if err != nil {
+		return err
+	}
+	if len(damaged) > 0 {
+		return fmt.Errorf("blocks %v are missing or corrupt", damaged)
+	}
+	fmt.Fprintf(stdout, "%s: all blocks are intact\n", stripe)
+	return nil
+}
+
+func info(f *flags, stripe string, stdout io.Writer) error {
+	manifest, err := goerasure.ReadManifest(stripe, f.options()...)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "stripe:     %s\n", stripe)
+	fmt.Fprintf(stdout, "technique:  %s\n", manifest.Technique)
+	fmt.Fprintf(stdout, "k:          %d\n", manifest.K)
+	fmt.Fprintf(stdout, "m:          %d\n", manifest.M)
+	fmt.Fprintf(stdout, "w:          %d\n", manifest.W)
+	fmt.Fprintf(stdout, "packetsize: %d\n", manifest.PacketSize)
+	fmt.Fprintf(stdout, "buffersize: %d\n", manifest.BufferSize)
+	fmt.Fprintf(stdout, "size:       %d\n", manifest.Size)
+	fmt.Fprintf(stdout, "hash:       %s\n", manifest.Hash)
+	if manifest.Naming != "" {
+		fmt.Fprintf(stdout, "naming:     %s\n", manifest.Naming)
+	}
+	if manifest.Placement != nil {
+		fmt.Fprintf(stdout, "placement:  %s\n", strings.Join(manifest.Placement, ","))
+	}
+	return nil
+}
+
+func repair(f *flags, stripe string, stdout io.Writer) error {
+	if err := goerasure.DecodeStripe(stripe, f.options()...); err != nil {
+		return err
+	}
+	return verify(f, stripe, stdout)
+}
+
+// usage prints the commands of goerasure.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: goerasure <command> [flags] <stripe>")
+	fmt.Fprintln(w, "\nThe commands are:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-7s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w, "\nRun goerasure <command> -h for the flags of a command.")
+}
+
+// run runs goerasure with the given arguments and returns its exit
+// status.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) < 1 {
+		usage(stderr)
+		return exitUsage
+	}
+	name := args[0]
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "goerasure: unknown command %q\n", name)
+		usage(stderr)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: goerasure %s [flags] <stripe>\n\n%s.\n\n", name, cmd.summary)
+		fs.PrintDefaults()
+	}
+	f := new(flags)
+	f.register(fs, cmd.code)
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	f.logger = log.New(stderr, "goerasure "+name+": ", 0)
+
+	if err := cmd.run(f, fs.Arg(0), stdout); err != nil {
+		fmt.Fprintf(stderr, "goerasure %s: %v\n", name, err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,146 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runCommand runs goerasure and returns its exit status and output.
+func runCommand(args ...string) (int, string) {
+	var out bytes.Buffer
+	status := run(args, &out, &out)
+	return status, out.String()
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	blocks := make([][]byte, 4)
+	for i := range blocks {
+		blocks[i] = make([]byte, 10000)
+		rand.Read(blocks[i])
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("stripe_k%d", i)), blocks[i], 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, technique := range []string{"reed_sol_van", "cauchy_orig", "cauchy_good", "liberation", "blaum_roth", "liber8tion"} {
+		w := map[string]string{"liberation": "7", "blaum_roth": "6"}[technique]
+		if w == "" {
+			w = "8"
+		}
+		if status, out := runCommand("encode", "-dir", dir, "-technique", technique, "-k", "4", "-m", "2", "-w", w, "-packetsize", "8", "stripe"); status != exitOK {
+			t.Fatalf("%s: encode exited with %d:\n%s", technique, status, out)
+		}
+		if status, out := runCommand("info", "-dir", dir, "stripe"); status != exitOK || !strings.Contains(out, "technique:  "+technique) {
+			t.Errorf("%s: info exited with %d:\n%s", technique, status, out)
+		}
+		if status, out := runCommand("verify", "-dir", dir, "stripe"); status != exitOK {
+			t.Errorf("%s: verify exited with %d:\n%s", technique, status, out)
+		}
+
+		os.Remove(filepath.Join(dir, "stripe_k1"))
+		os.WriteFile(filepath.Join(dir, "stripe_m0"), []byte("corrupt"), 0644)
+		if status, _ := runCommand("verify", "-dir", dir, "stripe"); status != exitFailure {
+			t.Errorf("%s: expected verify of a damaged stripe to fail, exited with %d", technique, status)
+		}
+		if status, out := runCommand("repair", "-dir", dir, "stripe"); status != exitOK {
+			t.Fatalf("%s: repair exited with %d:\n%s", technique, status, out)
+		}
+		decoded, err := os.ReadFile(filepath.Join(dir, "stripe_k1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded, blocks[1]) {
+			t.Errorf("%s: repaired data block differs from the original", technique)
+		}
+
+		os.Remove(filepath.Join(dir, "stripe_k3"))
+		if status, out := runCommand("decode", "-dir", dir, "-technique", technique, "-k", "4", "-m", "2", "-w", w, "-packetsize", "8", "stripe"); status != exitOK {
+			t.Fatalf("%s: decode exited with %d:\n%s", technique, status, out)
+		}
+		if status, out := runCommand("verify", "-dir", dir, "stripe"); status != exitOK {
+			t.Errorf("%s: verify after decode exited with %d:\n%s", technique, status, out)
+		}
+	}
+}
+
+// TestCommandBufferSize decodes a stripe encoded with a buffer size
+// without giving it again, which takes it from the manifest.
+func TestCommandBufferSize(t *testing.T) {
+	dir := t.TempDir()
+	blocks := make([][]byte, 4)
+	for i := range blocks {
+		blocks[i] = make([]byte, 21000)
+		rand.Read(blocks[i])
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("stripe_k%d", i)), blocks[i], 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if status, out := runCommand("encode", "-dir", dir, "-technique", "reed_sol_van", "-k", "4", "-m", "2", "-buffersize", "4096", "stripe"); status != exitOK {
+		t.Fatalf("encode exited with %d:\n%s", status, out)
+	}
+	os.Remove(filepath.Join(dir, "stripe_k1"))
+	os.Remove(filepath.Join(dir, "stripe_m0"))
+	if status, out := runCommand("decode", "-dir", dir, "-technique", "reed_sol_van", "-k", "4", "-m", "2", "stripe"); status != exitOK {
+		t.Fatalf("decode exited with %d:\n%s", status, out)
+	}
+	decoded, err := os.ReadFile(filepath.Join(dir, "stripe_k1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, blocks[1]) {
+		t.Error("decoded data block differs from the original")
+	}
+	if status, out := runCommand("verify", "-dir", dir, "stripe"); status != exitOK {
+		t.Errorf("verify after decode exited with %d:\n%s", status, out)
+	}
+
+	// A buffer size that does not match the manifest is refused
+	os.Remove(filepath.Join(dir, "stripe_k1"))
+	if status, _ := runCommand("decode", "-dir", dir, "-technique", "reed_sol_van", "-k", "4", "-m", "2", "-buffersize", "8192", "stripe"); status != exitFailure {
+		t.Errorf("expected decode with another buffer size to fail, exited with %d", status)
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		args   []string
+		status int
+	}{
+		{nil, exitUsage},
+		{[]string{"unknown", "stripe"}, exitUsage},
+		{[]string{"encode", "-k"}, exitUsage},
+		{[]string{"verify"}, exitUsage},
+		{[]string{"encode", "-dir", dir, "stripe"}, exitFailure},
+		{[]string{"encode", "-dir", dir, "-technique", "unknown", "-k", "4", "-m", "2", "stripe"}, exitFailure},
+		{[]string{"encode", "-dir", dir, "-technique", "reed_sol_van", "-k", "4", "-m", "2", "stripe"}, exitFailure},
+		{[]string{"info", "-dir", dir, "stripe"}, exitFailure},
+		{[]string{"repair", "-dir", dir, "stripe"}, exitFailure},
+	}
+	for _, test := range tests {
+		if status, out := runCommand(test.args...); status != test.status {
+			t.Errorf("%v: expected exit status %d, got %d:\n%s", test.args, test.status, status, out)
+		}
+	}
+}
@@ -1,5 +1,3 @@
-// +build linux
-
 //   Copyright 2013 Vastech SA (PTY) LTD
 //
 //   Licensed under the Apache License, Version 2.0 (the "License");
@@ -16,28 +14,9 @@
 
 package goerasure
 
-/*
-#include <stdio.h>
-#include "jerasure.h"
-#include "liberation.h"
-#include "reed_sol.h"
-#include "cauchy.h"
-
-char *jerasure_bytes_at(char **data, int i) {
-  return data[i];
-}
-
-int jerasure_int_at(int **schedule, int i, int j) {
-	return schedule[i][j];
-}
-
-*/
-import "C"
-
 import (
 	"fmt"
 	"math"
-	"unsafe"
 )
 
 // Coder impliments the interface that is used to endode and decode data.
@@ -45,23 +24,89 @@ import (
 // that allow for the retrieval of required variables.
 type Coder interface {
 	// Encode data and produce parity for the given code.
-	Encode(data, coding [][]byte)
+	Encode(data, coding [][]byte) error
 	// Decode data and parity with the specified erasures taken into
 	// account for the given code.
-	Decode(data, coding [][]byte, erasures []int)
+	Decode(data, coding [][]byte, erasures []int) error
 	// ValidateCode ensures that the coding parameters follow the
 	// specific code's requirements.
-	ValidateCode()
+	ValidateCode() error
 	// PrintInfo prints the coding parameters.
 	PrintInfo()
 	// Ensure that the file size is compatible with the coding parameters.
-	CheckFileSize(size int64)
+	CheckFileSize(size int64) error
 	// K retrieves the number of data blocks.
 	K() int
 	// M retrueves the number of parity blocks.
 	M() int
 	// Buffersize retrieves the buffer size
 	Buffersize() int64
+	// W retrieves the word size.
+	W() int
+	// PacketSize retrieves the packet size.
+	PacketSize() int
+	// Stats retrieves the work done by all calls to Encode and Decode
+	// of the code, as counted by jerasure_get_stats.
+	Stats() Stats
+	// Technique retrieves the name of the code, as accepted by NewCode.
+	Technique() string
+	// Close releases the coding matrices of the code. The code cannot
+	// be used after it was closed, and must not be closed while it is
+	// in use.
+	Close() error
+}
+
+// The names of the coding techniques, as used by NewCode and recorded in
+// stripe manifests. They match the names used by the jerasure examples.
+const (
+	ReedSolVan = "reed_sol_van"
+	ReedSolR6  = "reed_sol_r6_op"
+	CauchyOrig = "cauchy_orig"
+	CauchyGood = "cauchy_good"
+	Liberation = "liberation"
+	BlaumRoth  = "blaum_roth"
+	Liber8tion = "liber8tion"
+)
+
+// constructors maps the coding techniques to their constructors.
+var constructors = map[string]func(k, m, w, packetSize int, bufferSize int64) (Coder, error){
+	ReedSolVan: NewReedSolVanCode,
+	ReedSolR6:  newReedSolR6Code,
+	CauchyOrig: withDefaults(NewCauchyOrigCode),
+	CauchyGood: withDefaults(NewCauchyGoodCode),
+	Liberation: withDefaults(NewLiberationCode),
+	BlaumRoth:  withDefaults(NewBlaumRothCode),
+	Liber8tion: withDefaults(NewLiber8tionCode),
+}
+
+// withDefaults adapts the constructor of a bitmatrix code for the
+// constructors map, creating the code with the default options.
+func withDefaults(constructor func(k, m, w, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error)) func(k, m, w, packetSize int, bufferSize int64) (Coder, error) {
+	return func(k, m, w, packetSize int, bufferSize int64) (Coder, error) {
+		return constructor(k, m, w, packetSize, bufferSize)
+	}
+}
+
+// CauchyXY is the name of the codes returned by NewCauchyXYCode. NewCode
+// cannot create them, as the name does not record their X and Y sets.
+const CauchyXY = "cauchy_xy"
+
+// CustomMatrix and CustomBitmatrix are the names of the codes returned
+// by NewMatrixCode and NewBitmatrixCode. NewCode cannot create them, as
+// the names do not record their coding matrices.
+const (
+	CustomMatrix    = "matrix"
+	CustomBitmatrix = "bitmatrix"
+)
+
+// NewCode returns the code with the given technique name, such as
+// ReedSolVan or Liberation.
+func NewCode(technique string, k, m, w, packetSize int, bufferSize int64) (Coder, error) {
+	constructor, ok := constructors[technique]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTechnique, technique)
+	}
+	return constructor(k, m, w, packetSize, bufferSize)
 }
 
 // code is a generic type that specifies the basic variables that a
@@ -78,6 +123,13 @@ type code struct {
 	// bufferSize specifies over how many bytes of the file size
 	// encoding and decoding should occur.
 	bufferSize int64
+	// counters accumulates the work done by the code.
+	counters counters
+}
+
+// newCode returns the basic variables of a code.
+func newCode(k, m, w, packetSize int, bufferSize int64) code {
+	return code{k: k, m: m, w: w, packetSize: packetSize, bufferSize: bufferSize}
 }
 
 // PrintInfo prints the contents of the code type
@@ -87,48 +139,39 @@ func (this *code) PrintInfo() {
 
 // CheckFileSize ensures that a specific code can be matched to a
 // specific file size.
-// 
-// Both file size and buffer size have to be multiples of the code
-// parameters and the file size has to be a multiple of the buffer size.
-func (this *code) CheckFileSize(size int64) {
-	var multiple int64
-	var newsize float64
-
-	// Calculate the multiple of which both buffer size and file size
-	// must be a multiple
-	if this.packetSize != 0 {
-		multiple = int64(sizeInt) * int64(this.k) * int64(this.w) * int64(this.packetSize)
-	} else {
-		multiple = int64(sizeInt) * int64(this.k) * int64(this.w)
+//
+// The buffer size has to be a multiple of the code parameters. Files
+// that are not a multiple of the buffer size are padded while encoding,
+// so the file size itself is not restricted. If no buffer size was set,
+// it is set to the file size rounded up to a multiple of the parameters.
+func (this *code) CheckFileSize(size int64) error {
+	if size <= 0 {
+		return ErrNoData
+	}
+
+	multiple := this.multiple()
+
+	// If bufferSize was not set, set it to the padded file size.
+	if this.bufferSize == 0 {
+		this.bufferSize = roundUp(size, multiple)
+		return nil
 	}
 
 	// Check whether the buffer size is a valid multiple of the
 	// required coding parameters
-	if this.bufferSize != 0 {
-		newBuffersize := int64(math.Ceil(float64(this.bufferSize)/float64(multiple)) * float64(multiple))
-
-		if newBuffersize != this.bufferSize {
-			if newBuffersize <= size {
-				msg := fmt.Sprintf("Buffer size (%d) is not alligned to the coding parameters. Suggested buffer size: %d", this.bufferSize, newBuffersize)
-				panic(msg)
-			} else {
-				panic("Coding parameters are no valid for this small a file. Perhaps decrease the packet size.")
-			}
-		}
-		// If bufferSize was not set, set it equal to the file size.
-	} else {
-		this.bufferSize = size
+	if newBuffersize := roundUp(this.bufferSize, multiple); newBuffersize != this.bufferSize {
+		return fmt.Errorf("%w: buffer size %d, suggested buffer size %d", ErrMisalignedBuffer, this.bufferSize, newBuffersize)
 	}
+	return nil
+}
 
-	// Calculate the new file size that is a multiple of buffer size.
-	newsize = math.Ceil(float64(size)/float64(this.bufferSize)) * float64(this.bufferSize)
-	// Because we're using fixed size blocks, our old file size must be
-	// the same as our new file size, otherwise we have to select new
-	// coding parameters.
-	if newsize/float64(size) != 1 {
-		msg := fmt.Sprintf("File size is not a multiple of buffer size (%f). Suggested file size: %f", newsize, newsize)
-		panic(msg)
+// multiple calculates the multiple of which the buffer size must be a
+// multiple.
+func (this *code) multiple() int64 {
+	if this.packetSize != 0 {
+		return int64(sizeInt) * int64(this.k) * int64(this.w) * int64(this.packetSize)
 	}
+	return int64(sizeInt) * int64(this.k) * int64(this.w)
 }
 
 // K returns the number of data blocks in the stripe.
@@ -146,157 +189,124 @@ func (this *code) Buffersize() int64 {
 	return this.bufferSize
 }
 
-// matrixCode defines a type of code that uses a coding matrix.
-type matrixCode struct {
-	code
-	matrix *C.int
+// W returns the word size.
+func (this *code) W() int {
+	return this.w
 }
 
-// Encode encodes a matrix code, given a data block and writes the
-// output into the coding block
-func (this *matrixCode) Encode(data, coding [][]byte) {
-	dataC := blockToC(data)
-	codingC := blockToC(coding)
-	C.jerasure_matrix_encode(C.int(this.k), C.int(this.m), C.int(this.w), this.matrix, dataC, codingC, C.int(this.bufferSize))
-	cToBlock(dataC, data)
-	cToBlock(codingC, coding)
-	C.free(unsafe.Pointer(dataC))
-	C.free(unsafe.Pointer(codingC))
+// PacketSize returns the packet size.
+func (this *code) PacketSize() int {
+	return this.packetSize
 }
 
-// Decode decodes a matrix code, given (partially filled) data and
-// coding blocks and fills in the missing slices in the blocks.
-func (this *matrixCode) Decode(data, coding [][]byte, erasures []int) {
-
-	dataC := blockToC(data)
-	codingC := blockToC(coding)
-
-	erasuresC := intSliceToC(erasures)
-
-	// TODO: Buffersize is int64, but the encoding and decoding methods
-	// use int. This should probably be made int in the go code as well,
-	// to enforce correctness.
-	ret := C.jerasure_matrix_decode(C.int(this.k), C.int(this.m), C.int(this.w), this.matrix, 1, erasuresC, dataC, codingC, C.int(this.bufferSize))
-	if ret == -1 {
-		panic("Erasure decoding failed")
-	}
-
-	cToBlock(dataC, data)
-	cToBlock(codingC, coding)
-	C.free(unsafe.Pointer(dataC))
-	C.free(unsafe.Pointer(codingC))
+// Stats returns the work done by the code since it was created.
+func (this *code) Stats() Stats {
+	return this.counters.load()
 }
 
-// bitMatrixCode defines a type of code that uses a coding bit matrix
-// and schedule
-//
-// The schedule is calculated from the bitmatrix and used for efficient
-// encoding. The bitmatrix is also used for decoding.
-type bitmatrixCode struct {
-	code
-	bitmatrix *C.int
-	schedule  **C.int
+// record adds the work of a call to Encode or Decode to the statistics
+// of the code.
+func (this *code) record(stats Stats, err error) (Stats, error) {
+	this.counters.add(stats)
+	return stats, err
 }
 
-// Encode encodes a bit matrix code, given a data block and writes the
-// output into the coding block
-func (this *bitmatrixCode) Encode(data, coding [][]byte) {
-	dataC := blockToC(data)
-	codingC := blockToC(coding)
-
-	C.jerasure_schedule_encode(C.int(this.k), C.int(this.m), C.int(this.w), this.schedule, dataC, codingC, C.int(this.bufferSize), C.int(this.packetSize))
-
-	cToBlock(dataC, data)
-	cToBlock(codingC, coding)
-	C.free(unsafe.Pointer(dataC))
-	C.free(unsafe.Pointer(codingC))
+// checkBlocks ensures that the data and coding blocks handed to Encode
+// or Decode match the coding parameters, before they are passed to C.
+func (this *code) checkBlocks(data, coding [][]byte) error {
+	if len(data) < 1 {
+		return ErrNoData
+	}
+	if len(data) != this.k || len(coding) != this.m {
+		return fmt.Errorf("%w: got %d data and %d coding blocks, want %d and %d", ErrInvalidParams, len(data), len(coding), this.k, this.m)
+	}
+	if this.bufferSize <= 0 {
+		return fmt.Errorf("%w: buffer size has not been set", ErrInvalidParams)
+	}
+	for _, block := range [][][]byte{data, coding} {
+		for i := range block {
+			if int64(len(block[i])) != this.bufferSize {
+				return fmt.Errorf("%w: block of %d bytes, buffer size is %d", ErrMisalignedBuffer, len(block[i]), this.bufferSize)
+			}
+		}
+	}
+	return nil
 }
 
-// Decode decodes a bit matrix code, given (partially filled) data and
-// coding blocks and fills in the missing slices in the blocks.
-func (this *bitmatrixCode) Decode(data, coding [][]byte, erasures []int) {
-
-	dataC := blockToC(data)
-	codingC := blockToC(coding)
-
-	erasuresC := intSliceToC(erasures)
-
-	// TODO: Buffersize is int64, but the encoding and decoding methods
-	// use int.
-	// This should probably be made int in the go code as well, to enforce
-	// correctness, although that will also limit file sizes to int
-	ret := C.jerasure_schedule_decode_lazy(C.int(this.k), C.int(this.m), C.int(this.w), this.bitmatrix, erasuresC, dataC, codingC, C.int(this.bufferSize), C.int(this.packetSize), 1)
-	if ret == -1 {
-		panic("Erasure decoding failed")
+// checkErasures ensures that an erasure list is terminated by -1, only
+// refers to blocks in the stripe and can be recovered from.
+func (this *code) checkErasures(erasures []int) error {
+	num, err := numErasures(erasures)
+	if err != nil {
+		return err
+	}
+	if num > this.m {
+		return fmt.Errorf("%w: found %d erasures with only %d parities", ErrTooManyErasures, num, this.m)
+	}
+	for _, id := range erasures[:num] {
+		if id < 0 || id >= this.k+this.m {
+			return fmt.Errorf("%w: erasure %d is not a block in the stripe", ErrInvalidParams, id)
+		}
 	}
+	return nil
+}
 
-	cToBlock(dataC, data)
-	cToBlock(codingC, coding)
-	C.free(unsafe.Pointer(dataC))
-	C.free(unsafe.Pointer(codingC))
+// reedSolVanCode is a type of matrixCode
+type reedSolVanCode struct {
+	matrixCode
 }
 
-// CToBlock received a C char matrix as input and outputs a Go byte matrix. 
-func cToBlock(dataC **C.char, data [][]byte) {
-	for i := range data {
-		data[i] = C.GoBytes(unsafe.Pointer(C.jerasure_bytes_at(dataC, C.int(i))), C.int(len(data[i])))
-	}
+// Technique returns the name of the code.
+func (this *reedSolVanCode) Technique() string {
+	return ReedSolVan
 }
 
-// blockToC receives a Go byte matrix as input and outputs a C char matrix.
-func blockToC(data [][]byte) **C.char {
-	if len(data) < 1 {
-		panic("no data given")
-	}
-	
-	var b *C.char
-	ptrSize := unsafe.Sizeof(b)
-	
-	//Allocate the char** list
-	ptr := C.malloc(C.size_t(len(data)) * C.size_t(ptrSize))
-	
-	//Assign each byte slice to its appropriate offset
-	for i := 0 ; i < len(data) ; i++ {
-		element := (**C.char)(unsafe.Pointer(uintptr(ptr) + uintptr(i)*ptrSize))
-		*element = (*C.char)(unsafe.Pointer(&data[i][0]))
+// ValidateCode validates the code to ensure that the chosen coding
+// parameters match what the code allows.
+func (this *reedSolVanCode) ValidateCode() error {
+	if err := checkArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize); err != nil {
+		return err
 	}
 
-	return ((**C.char)(ptr))
+	if this.w != 8 && this.w != 16 && this.w != 32 {
+		return fmt.Errorf("%w: word size must be 8, 16 or 32", ErrInvalidParams)
+	}
+	return nil
 }
 
-// NewReedSolVanCode returns a Reed-Solomon code, and initialising the
-// coding matrix to a Vandermonde matrix
-func NewReedSolVanCode(k, m, w, packetSize int, bufferSize int64) Coder {
-	code := &reedSolVanCode{matrixCode{code{k, m, w, packetSize, bufferSize}, nil}}
-	code.matrix = C.reed_sol_vandermonde_coding_matrix(C.int(k), C.int(m), C.int(w))
-	code.ValidateCode()
-	return code
+// NewReedSolR6Code returns a RAID-6 Reed-Solomon code, with m = 2. The
+// first coding block is the parity of the data blocks and the second the
+// sum of data block j multiplied by 2^j, which is computed by repeatedly
+// multiplying by two instead of with the coding matrix. Decoding uses
+// the coding matrix.
+func NewReedSolR6Code(k, w, packetSize int, bufferSize int64) (Coder, error) {
+	return newReedSolR6Code(k, 2, w, packetSize, bufferSize)
 }
 
-// reedSolVanCode is a type of matrixCode
-type reedSolVanCode struct {
+// reedSolR6Code is a type of matrixCode with a faster encoder
+type reedSolR6Code struct {
 	matrixCode
 }
 
+// Technique returns the name of the code.
+func (this *reedSolR6Code) Technique() string {
+	return ReedSolR6
+}
+
 // ValidateCode validates the code to ensure that the chosen coding
 // parameters match what the code allows.
-func (this *reedSolVanCode) ValidateCode() {
-	checkArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize)
+func (this *reedSolR6Code) ValidateCode() error {
+	if err := checkArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize); err != nil {
+		return err
+	}
 
 	if this.w != 8 && this.w != 16 && this.w != 32 {
-		panic("word size must be 8, 16 or 32")
+		return fmt.Errorf("%w: word size must be 8, 16 or 32", ErrInvalidParams)
 	}
-}
-
-// NewCaucheOrigCode returns a type of bitmatrix code with both the
-// bitmatrix and schedule initialised.
-func NewCauchyOrigCode(k, m, w, packetSize int, bufferSize int64) Coder {
-	code := &cauchyOrigCode{bitmatrixCode{code{k, m, w, packetSize, bufferSize}, nil, nil}}
-	matrix := C.cauchy_original_coding_matrix(C.int(k), C.int(m), C.int(w))
-	code.bitmatrix = C.jerasure_matrix_to_bitmatrix(C.int(k), C.int(m), C.int(w), matrix)
-	code.schedule = C.jerasure_smart_bitmatrix_to_schedule(C.int(k), C.int(m), C.int(w), code.bitmatrix)
-	code.ValidateCode()
-	return code
+	if this.m != 2 {
+		return fmt.Errorf("%w: m must equal 2", ErrInvalidParams)
+	}
+	return nil
 }
 
 // cauchyOrigCode is a type of bitmatrixCode
@@ -304,50 +314,72 @@ type cauchyOrigCode struct {
 	bitmatrixCode
 }
 
+// Technique returns the name of the code.
+func (this *cauchyOrigCode) Technique() string {
+	return CauchyOrig
+}
+
 // ValidateCode validates the code to ensure that the chosen coding
 // parameters match what the code allows.
-func (this *cauchyOrigCode) ValidateCode() {
-	checkArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize)
+func (this *cauchyOrigCode) ValidateCode() error {
+	return checkCauchyArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize)
+}
 
-	if this.packetSize == 0 {
-		panic("packetSize > 0 required")
-	}
+// caucheGoodCode is a type of bitmatrixCode
+type cauchyGoodCode struct {
+	bitmatrixCode
 }
 
-// NewCaucheGoodCode returns a type of bitmatrix code with both the
-// bitmatrix and schedule initialised.
-func NewCauchyGoodCode(k, m, w, packetSize int, bufferSize int64) Coder {
-	code := &cauchyGoodCode{bitmatrixCode{code{k, m, w, packetSize, bufferSize}, nil, nil}}
-	matrix := C.cauchy_good_general_coding_matrix(C.int(k), C.int(m), C.int(w))
-	code.bitmatrix = C.jerasure_matrix_to_bitmatrix(C.int(k), C.int(m), C.int(w), matrix)
-	code.schedule = C.jerasure_smart_bitmatrix_to_schedule(C.int(k), C.int(m), C.int(w), code.bitmatrix)
-	code.ValidateCode()
-	return code
+// Technique returns the name of the code.
+func (this *cauchyGoodCode) Technique() string {
+	return CauchyGood
 }
 
-// caucheGoodCode is a type of bitmatrixCode
-type cauchyGoodCode struct {
+// ValidateCode validates the code to ensure that the chosen coding
+// parameters match what the code allows.
+func (this *cauchyGoodCode) ValidateCode() error {
+	return checkCauchyArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize)
+}
+
+// cauchyXYCode is a type of bitmatrixCode
+type cauchyXYCode struct {
 	bitmatrixCode
+	// x and y are the elements of GF(2^w) that define the rows and the
+	// columns of the Cauchy matrix.
+	x, y []int
+}
+
+// Technique returns the name of the code.
+func (this *cauchyXYCode) Technique() string {
+	return CauchyXY
 }
 
 // ValidateCode validates the code to ensure that the chosen coding
 // parameters match what the code allows.
-func (this *cauchyGoodCode) ValidateCode() {
-	checkArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize)
+func (this *cauchyXYCode) ValidateCode() error {
+	if err := checkCauchyArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize); err != nil {
+		return err
+	}
 
-	if this.packetSize == 0 {
-		panic("packetSize > 0 required")
+	if len(this.x) != this.m || len(this.y) != this.k {
+		return fmt.Errorf("%w: X must have m elements and Y k elements", ErrInvalidParams)
 	}
-}
 
-// NewLiberationCode returns a type of bitmatrix code with both the
-// bitmatrix and schedule initialised.
-func NewLiberationCode(k, m, w, packetSize int, bufferSize int64) Coder {
-	code := &liberationCode{bitmatrixCode{code{k, m, w, packetSize, bufferSize}, nil, nil}}
-	code.bitmatrix = C.liberation_coding_bitmatrix(C.int(k), C.int(w))
-	code.schedule = C.jerasure_smart_bitmatrix_to_schedule(C.int(k), C.int(m), C.int(w), code.bitmatrix)
-	code.ValidateCode()
-	return code
+	// The elements have to be distinct, or the matrix would either divide
+	// by zero or not be invertible
+	seen := make(map[int]bool, this.k+this.m)
+	for _, set := range [][]int{this.x, this.y} {
+		for _, e := range set {
+			if e < 0 || (this.w < 32 && e >= 1<<uint(this.w)) || int64(e) > math.MaxUint32 {
+				return fmt.Errorf("%w: %d is not an element of GF(2^%d)", ErrInvalidParams, e, this.w)
+			}
+			if seen[e] {
+				return fmt.Errorf("%w: %d appears more than once in X and Y", ErrInvalidParams, e)
+			}
+			seen[e] = true
+		}
+	}
+	return nil
 }
 
 // liberationCode is a type of bitmatrixCode
@@ -355,33 +387,34 @@ type liberationCode struct {
 	bitmatrixCode
 }
 
+// Technique returns the name of the code.
+func (this *liberationCode) Technique() string {
+	return Liberation
+}
+
 // ValidateCode validates the code to ensure that the chosen coding
 // parameters match what the code allows.
-func (this *liberationCode) ValidateCode() {
-	checkArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize)
+func (this *liberationCode) ValidateCode() error {
+	if err := checkArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize); err != nil {
+		return err
+	}
 
 	if this.k > this.w {
-		panic("k must be less than or equal to w")
+		return fmt.Errorf("%w: k must be less than or equal to w", ErrInvalidParams)
 	}
 	if this.w <= 2 || !isPrime(this.w) {
-		panic("w must be greater than two and w must be prime")
+		return fmt.Errorf("%w: w must be greater than two and w must be prime", ErrInvalidParams)
+	}
+	if this.m != 2 {
+		return fmt.Errorf("%w: m must equal 2", ErrInvalidParams)
 	}
 	if this.packetSize == 0 {
-		panic("packetSize > 0 required")
+		return fmt.Errorf("%w: packetSize > 0 required", ErrInvalidParams)
 	}
 	if this.packetSize%sizeInt != 0 {
-		panic("packetSize must be a multiple of sizeof(int64) == 8")
+		return fmt.Errorf("%w: packetSize must be a multiple of sizeof(int64) == 8", ErrInvalidParams)
 	}
-}
-
-// NewBlaumRothCode returns a type of bitmatrix code with both the
-// bitmatrix and schedule initialised.
-func NewBlaumRothCode(k, m, w, packetSize int, bufferSize int64) Coder {
-	code := &blaumRothCode{bitmatrixCode{code{k, m, w, packetSize, bufferSize}, nil, nil}}
-	code.bitmatrix = C.blaum_roth_coding_bitmatrix(C.int(k), C.int(w))
-	code.schedule = C.jerasure_smart_bitmatrix_to_schedule(C.int(k), C.int(m), C.int(w), code.bitmatrix)
-	code.ValidateCode()
-	return code
+	return nil
 }
 
 // blaumRothCode is a type of bitmatrixCode
@@ -389,33 +422,34 @@ type blaumRothCode struct {
 	bitmatrixCode
 }
 
+// Technique returns the name of the code.
+func (this *blaumRothCode) Technique() string {
+	return BlaumRoth
+}
+
 // ValidateCode validates the code to ensure that the chosen coding
 // parameters match what the code allows.
-func (this *blaumRothCode) ValidateCode() {
-	checkArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize)
+func (this *blaumRothCode) ValidateCode() error {
+	if err := checkArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize); err != nil {
+		return err
+	}
 
 	if this.k > this.w {
-		panic("k must be less than or equal to w")
+		return fmt.Errorf("%w: k must be less than or equal to w", ErrInvalidParams)
 	}
 	if this.w <= 2 || !isPrime(this.w+1) {
-		panic("w must be greater than two and w must be prime")
+		return fmt.Errorf("%w: w must be greater than two and w+1 must be prime", ErrInvalidParams)
+	}
+	if this.m != 2 {
+		return fmt.Errorf("%w: m must equal 2", ErrInvalidParams)
 	}
 	if this.packetSize == 0 {
-		panic("packetSize > 0 required")
+		return fmt.Errorf("%w: packetSize > 0 required", ErrInvalidParams)
 	}
 	if this.packetSize%sizeInt != 0 {
-		panic("packetSize must be a multiple of sizeof(int64) == 8")
+		return fmt.Errorf("%w: packetSize must be a multiple of sizeof(int64) == 8", ErrInvalidParams)
 	}
-}
-
-// NewLiber8tionCode returns a type of bitmatrix code with both the
-// bitmatrix and schedule initialised.
-func NewLiber8tionCode(k, m, w, packetSize int, bufferSize int64) Coder {
-	code := &liber8tionCode{bitmatrixCode{code{k, m, w, packetSize, bufferSize}, nil, nil}}
-	code.bitmatrix = C.liber8tion_coding_bitmatrix(C.int(k))
-	code.schedule = C.jerasure_smart_bitmatrix_to_schedule(C.int(k), C.int(m), C.int(w), code.bitmatrix)
-	code.ValidateCode()
-	return code
+	return nil
 }
 
 // liber8tionCode is a type of bitmatrixCode
@@ -423,49 +457,165 @@ type liber8tionCode struct {
 	bitmatrixCode
 }
 
+// Technique returns the name of the code.
+func (this *liber8tionCode) Technique() string {
+	return Liber8tion
+}
+
 // ValidateCode validates the code to ensure that the chosen coding
 // parameters match what the code allows.
-func (this *liber8tionCode) ValidateCode() {
-	checkArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize)
+func (this *liber8tionCode) ValidateCode() error {
+	if err := checkArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize); err != nil {
+		return err
+	}
 
 	if this.k > this.w {
-		panic("k must be less than or equal to w")
+		return fmt.Errorf("%w: k must be less than or equal to w", ErrInvalidParams)
 	}
 	if this.w != 8 {
-		panic("w must equal 8")
+		return fmt.Errorf("%w: w must equal 8", ErrInvalidParams)
 	}
 	if this.m != 2 {
-		panic("m must equal 2")
+		return fmt.Errorf("%w: m must equal 2", ErrInvalidParams)
 	}
 	if this.packetSize == 0 {
-		panic("packetSize > 0 required")
+		return fmt.Errorf("%w: packetSize > 0 required", ErrInvalidParams)
 	}
+	return nil
+}
+
+// customMatrixCode is a type of matrixCode with a coding matrix given
+// by the caller.
+type customMatrixCode struct {
+	matrixCode
+	// elements holds the m x k coding matrix row by row.
+	elements []int
+}
+
+// Technique returns the name of the code.
+func (this *customMatrixCode) Technique() string {
+	return CustomMatrix
+}
+
+// ValidateCode validates the code to ensure that the chosen coding
+// parameters match what the code allows. The coding matrix has to be
+// MDS, so that any k blocks of a stripe can be decoded.
+func (this *customMatrixCode) ValidateCode() error {
+	if err := checkArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize); err != nil {
+		return err
+	}
+
+	if this.w != 8 && this.w != 16 && this.w != 32 {
+		return fmt.Errorf("%w: word size must be 8, 16 or 32", ErrInvalidParams)
+	}
+	if len(this.elements) != this.k*this.m {
+		return fmt.Errorf("%w: coding matrix must have m rows of k elements", ErrInvalidParams)
+	}
+	for _, e := range this.elements {
+		if e < 0 || int64(e) >= 1<<uint(this.w) {
+			return fmt.Errorf("%w: %d is not an element of GF(2^%d)", ErrInvalidParams, e, this.w)
+		}
+	}
+	if !isMDS(this.k, this.m, this.w, this.elements) {
+		return fmt.Errorf("%w: coding matrix is not MDS", ErrInvalidParams)
+	}
+	return nil
+}
+
+// customBitmatrixCode is a type of bitmatrixCode with a coding bitmatrix
+// given by the caller.
+type customBitmatrixCode struct {
+	bitmatrixCode
+	// bits holds the mw x kw coding bitmatrix row by row.
+	bits []int
+}
+
+// Technique returns the name of the code.
+func (this *customBitmatrixCode) Technique() string {
+	return CustomBitmatrix
+}
+
+// ValidateCode validates the code to ensure that the chosen coding
+// parameters match what the code allows. The coding bitmatrix has to be
+// MDS, so that any k blocks of a stripe can be decoded.
+func (this *customBitmatrixCode) ValidateCode() error {
+	if err := checkArgs(this.k, this.m, this.w, this.packetSize, this.bufferSize); err != nil {
+		return err
+	}
+
+	if this.w > 32 {
+		return fmt.Errorf("%w: w must be less than or equal to 32", ErrInvalidParams)
+	}
+	if this.packetSize == 0 {
+		return fmt.Errorf("%w: packetSize > 0 required", ErrInvalidParams)
+	}
+	if len(this.bits) != this.k*this.m*this.w*this.w {
+		return fmt.Errorf("%w: coding bitmatrix must have mw rows of kw bits", ErrInvalidParams)
+	}
+	for _, b := range this.bits {
+		if b != 0 && b != 1 {
+			return fmt.Errorf("%w: bitmatrix element %d is not a bit", ErrInvalidParams, b)
+		}
+	}
+	if !isMDSBitmatrix(this.k, this.m, this.w, this.bits) {
+		return fmt.Errorf("%w: coding bitmatrix is not MDS", ErrInvalidParams)
+	}
+	return nil
+}
+
+// flattenCodingMatrix joins the rows of a coding (bit)matrix given to
+// NewMatrixCode or NewBitmatrixCode, which has to be rows x cols.
+func flattenCodingMatrix(matrix [][]int, rows, cols int) ([]int, error) {
+	elements, c, err := flatten(matrix)
+	if err != nil {
+		return nil, err
+	}
+	if len(matrix) != rows || c != cols {
+		return nil, fmt.Errorf("%w: coding matrix is %dx%d, expected %dx%d", ErrInvalidParams, len(matrix), c, rows, cols)
+	}
+	return elements, nil
 }
 
 // checkArgs performs sanity checking on the coding parameters provided,
 // to ensure that all are positive.
-func checkArgs(k, m, w, packetSize int, bufferSize int64) {
+func checkArgs(k, m, w, packetSize int, bufferSize int64) error {
 	if k <= 0 {
-		panic("k <= 0")
+		return fmt.Errorf("%w: k <= 0", ErrInvalidParams)
 	}
-	if m < 0 {
-		panic("m < 0")
+	if m <= 0 {
+		return fmt.Errorf("%w: m <= 0", ErrInvalidParams)
 	}
 	if w <= 0 {
-		panic("w <= 0")
+		return fmt.Errorf("%w: w <= 0", ErrInvalidParams)
 	}
 	if packetSize < 0 {
-		panic("packetSize < 0")
+		return fmt.Errorf("%w: packetSize < 0", ErrInvalidParams)
 	}
 	if bufferSize < 0 {
-		panic("bufferSize < 0")
+		return fmt.Errorf("%w: bufferSize < 0", ErrInvalidParams)
+	}
+	return nil
+}
+
+// checkCauchyArgs performs the checks shared by the Cauchy codes. The
+// Cauchy matrix needs k+m distinct elements in GF(2^w).
+func checkCauchyArgs(k, m, w, packetSize int, bufferSize int64) error {
+	if err := checkArgs(k, m, w, packetSize, bufferSize); err != nil {
+		return err
+	}
+
+	if w > 32 || (w < 31 && k+m > 1<<uint(w)) {
+		return fmt.Errorf("%w: k+m must be less than or equal to 2^w, with w <= 32", ErrInvalidParams)
+	}
+	if packetSize == 0 {
+		return fmt.Errorf("%w: packetSize > 0 required", ErrInvalidParams)
 	}
+	return nil
 }
 
 // isPrime is an efficient implementation to check whether any number
-// below 512 is prime.
+// up to 257 is prime. Larger numbers are reported as not prime.
 func isPrime(n int) bool {
-	fmt.Printf("n is %d\n", n)
 	primes := []int{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53, 59, 61, 67, 71,
 		73, 79, 83, 89, 97, 101, 103, 107, 109, 113, 127, 131, 137, 139, 149, 151, 157, 163, 167, 173, 179,
 		181, 191, 193, 197, 199, 211, 223, 227, 229, 233, 239, 241, 251, 257}
@@ -477,5 +627,5 @@ func isPrime(n int) bool {
 			return false
 		}
 	}
-	panic("n > 512")
+	return false
 }
@@ -0,0 +1,694 @@
+//go:build linux && cgo && !purego
+// +build linux,cgo,!purego
+
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+/*
+// galois.c accesses the same memory through pointers of different types,
+// which breaks the w = 16 region multiply at -O2.
+#cgo CFLAGS: -fno-strict-aliasing
+
+#include <stdio.h>
+#include "jerasure.h"
+#include "liberation.h"
+#include "reed_sol.h"
+#include "cauchy.h"
+
+int jerasure_int_at(int **schedule, int i, int j) {
+	return schedule[i][j];
+}
+
+*/
+import "C"
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// matrixCode defines a type of code that uses a coding matrix.
+type matrixCode struct {
+	code
+	matrix *C.int
+}
+
+// Encode encodes a matrix code, given a data block and writes the
+// output into the coding block
+func (this *matrixCode) Encode(data, coding [][]byte) error {
+	_, err := this.record(this.encodeStats(data, coding))
+	return err
+}
+
+// encodeStats encodes the blocks like Encode and returns the work that
+// jerasure did.
+func (this *matrixCode) encodeStats(data, coding [][]byte) (Stats, error) {
+	if this.matrix == nil {
+		return Stats{}, ErrClosed
+	}
+	// The finalizer must not free the code while C uses it
+	defer runtime.KeepAlive(this)
+
+	if err := this.checkBlocks(data, coding); err != nil {
+		return Stats{}, err
+	}
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+	dataC := pinBlocks(&pinner, data)
+	codingC := pinBlocks(&pinner, coding)
+
+	return measure(func() {
+		C.jerasure_matrix_encode(C.int(this.k), C.int(this.m), C.int(this.w), this.matrix, dataC, codingC, C.int(this.bufferSize))
+	}), nil
+}
+
+// Decode decodes a matrix code, given (partially filled) data and
+// coding blocks and fills in the missing slices in the blocks.
+func (this *matrixCode) Decode(data, coding [][]byte, erasures []int) error {
+	_, err := this.record(this.decodeStats(data, coding, erasures))
+	return err
+}
+
+// decodeStats decodes the blocks like Decode and returns the work that
+// jerasure did.
+func (this *matrixCode) decodeStats(data, coding [][]byte, erasures []int) (Stats, error) {
+	if this.matrix == nil {
+		return Stats{}, ErrClosed
+	}
+	defer runtime.KeepAlive(this)
+
+	if err := this.checkBlocks(data, coding); err != nil {
+		return Stats{}, err
+	}
+	if err := this.checkErasures(erasures); err != nil {
+		return Stats{}, err
+	}
+
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+	dataC := pinBlocks(&pinner, data)
+	codingC := pinBlocks(&pinner, coding)
+
+	erasuresC := intSliceToC(erasures)
+
+	// TODO: Buffersize is int64, but the encoding and decoding methods
+	// use int. This should probably be made int in the go code as well,
+	// to enforce correctness.
+	var ret C.int
+	stats := measure(func() {
+		ret = C.jerasure_matrix_decode(C.int(this.k), C.int(this.m), C.int(this.w), this.matrix, this.rowKOnes(), erasuresC, dataC, codingC, C.int(this.bufferSize))
+	})
+	if ret == -1 {
+		return stats, ErrDecodeFailed
+	}
+	return stats, nil
+}
+
+// bitMatrixCode defines a type of code that uses a coding bit matrix
+// and schedule
+//
+// The schedule is calculated from the bitmatrix and used for efficient
+// encoding. The bitmatrix is also used for decoding.
+type bitmatrixCode struct {
+	code
+	bitmatrix *C.int
+	schedule  **C.int
+	// execution selects whether the code is computed with the schedule
+	// or directly with the bitmatrix.
+	execution Execution
+	// cache holds the decoding schedules of codes with m = 2, which are
+	// created on the first decode.
+	cache     ***C.int
+	cacheOnce sync.Once
+}
+
+// setExecution creates the encoding schedule that the execution selected
+// by opts needs.
+func (this *bitmatrixCode) setExecution(opts []CodeOption) error {
+	o, err := newCodeOptions(opts)
+	if err != nil {
+		return err
+	}
+	this.execution = o.execution
+
+	k, m, w := C.int(this.k), C.int(this.m), C.int(this.w)
+	switch this.execution {
+	case SmartSchedule:
+		this.schedule = C.jerasure_smart_bitmatrix_to_schedule(k, m, w, this.bitmatrix)
+	case DumbSchedule:
+		this.schedule = C.jerasure_dumb_bitmatrix_to_schedule(k, m, w, this.bitmatrix)
+	}
+	return nil
+}
+
+// smart reports to jerasure whether decoding schedules have to be
+// created with the smart scheduler.
+func (this *bitmatrixCode) smart() C.int {
+	if this.execution == SmartSchedule {
+		return 1
+	}
+	return 0
+}
+
+// Encode encodes a bit matrix code, given a data block and writes the
+// output into the coding block
+func (this *bitmatrixCode) Encode(data, coding [][]byte) error {
+	_, err := this.record(this.encodeStats(data, coding))
+	return err
+}
+
+// encodeStats encodes the blocks like Encode and returns the work that
+// jerasure did.
+func (this *bitmatrixCode) encodeStats(data, coding [][]byte) (Stats, error) {
+	if this.bitmatrix == nil {
+		return Stats{}, ErrClosed
+	}
+	defer runtime.KeepAlive(this)
+
+	if err := this.checkBlocks(data, coding); err != nil {
+		return Stats{}, err
+	}
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+	dataC := pinBlocks(&pinner, data)
+	codingC := pinBlocks(&pinner, coding)
+
+	return measure(func() {
+		if this.execution == NoSchedule {
+			C.jerasure_bitmatrix_encode(C.int(this.k), C.int(this.m), C.int(this.w), this.bitmatrix, dataC, codingC, C.int(this.bufferSize), C.int(this.packetSize))
+		} else {
+			C.jerasure_schedule_encode(C.int(this.k), C.int(this.m), C.int(this.w), this.schedule, dataC, codingC, C.int(this.bufferSize), C.int(this.packetSize))
+		}
+	}), nil
+}
+
+// Decode decodes a bit matrix code, given (partially filled) data and
+// coding blocks and fills in the missing slices in the blocks.
+func (this *bitmatrixCode) Decode(data, coding [][]byte, erasures []int) error {
+	_, err := this.record(this.decodeStats(data, coding, erasures))
+	return err
+}
+
+// decodeStats decodes the blocks like Decode and returns the work that
+// jerasure did.
+func (this *bitmatrixCode) decodeStats(data, coding [][]byte, erasures []int) (Stats, error) {
+	if this.bitmatrix == nil {
+		return Stats{}, ErrClosed
+	}
+	defer runtime.KeepAlive(this)
+
+	if err := this.checkBlocks(data, coding); err != nil {
+		return Stats{}, err
+	}
+	if err := this.checkErasures(erasures); err != nil {
+		return Stats{}, err
+	}
+	return this.decode(data, coding, erasures, this.scheduleCache())
+}
+
+// decode decodes the blocks with the decoding schedules in cache, or
+// with a new decoding schedule if cache is nil.
+func (this *bitmatrixCode) decode(data, coding [][]byte, erasures []int, cache ***C.int) (Stats, error) {
+	if cache != nil && erasures[0] == -1 {
+		// The cache has no schedule for decoding nothing
+		return Stats{}, nil
+	}
+
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+	dataC := pinBlocks(&pinner, data)
+	codingC := pinBlocks(&pinner, coding)
+
+	erasuresC := intSliceToC(erasures)
+
+	// TODO: Buffersize is int64, but the encoding and decoding methods
+	// use int.
+	// This should probably be made int in the go code as well, to enforce
+	// correctness, although that will also limit file sizes to int
+	k, m, w := C.int(this.k), C.int(this.m), C.int(this.w)
+	size, packetSize := C.int(this.bufferSize), C.int(this.packetSize)
+	var ret C.int
+	stats := measure(func() {
+		switch {
+		case this.execution == NoSchedule:
+			ret = C.jerasure_bitmatrix_decode(k, m, w, this.bitmatrix, this.rowKOnes(), erasuresC, dataC, codingC, size, packetSize)
+		case cache != nil:
+			ret = C.jerasure_schedule_decode_cache(k, m, w, cache, erasuresC, dataC, codingC, size, packetSize)
+		default:
+			ret = C.jerasure_schedule_decode_lazy(k, m, w, this.bitmatrix, erasuresC, dataC, codingC, size, packetSize, this.smart())
+		}
+	})
+	if ret == -1 {
+		return stats, ErrDecodeFailed
+	}
+	return stats, nil
+}
+
+// scheduleCache returns the decoding schedules for every erasure of one
+// or two blocks of a scheduled code with m = 2, creating them on first
+// use. Other codes have no cache and create a decoding schedule on every
+// decode.
+func (this *bitmatrixCode) scheduleCache() ***C.int {
+	if this.m != 2 || this.execution == NoSchedule {
+		return nil
+	}
+	this.cacheOnce.Do(func() {
+		this.cache = C.jerasure_generate_schedule_cache(C.int(this.k), C.int(this.m), C.int(this.w), this.bitmatrix, this.smart())
+	})
+	return this.cache
+}
+
+// rowKOnes reports to jerasure_bitmatrix_decode whether the first w rows
+// of the coding bitmatrix compute the parity of the data blocks.
+func (this *bitmatrixCode) rowKOnes() C.int {
+	k, w := this.k, this.w
+	for i, b := range unsafe.Slice(this.bitmatrix, k*w*w) {
+		row, col := i/(k*w), i%(k*w)
+		if (b == 1) != (row == col%w) {
+			return 0
+		}
+	}
+	return 1
+}
+
+// measure calls fn, which calls into jerasure, and returns the work that
+// jerasure did. The counters of jerasure are kept per thread, so the
+// goroutine is locked to its thread while they are read.
+func measure(fn func()) Stats {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// jerasure_get_stats resets the counters after reading them
+	var counters [3]C.double
+	C.jerasure_get_stats(&counters[0])
+	fn()
+	C.jerasure_get_stats(&counters[0])
+	return Stats{
+		XORBytes:  int64(counters[0]),
+		GFBytes:   int64(counters[1]),
+		CopyBytes: int64(counters[2]),
+	}
+}
+
+// Close frees the coding matrix. The code cannot be used afterwards.
+func (this *matrixCode) Close() error {
+	if this.matrix != nil {
+		C.free(unsafe.Pointer(this.matrix))
+		this.matrix = nil
+	}
+	return nil
+}
+
+// Close frees the coding bitmatrix and schedules. The code cannot be
+// used afterwards.
+func (this *bitmatrixCode) Close() error {
+	if this.cache != nil {
+		C.jerasure_free_schedule_cache(C.int(this.k), C.int(this.m), this.cache)
+		this.cache = nil
+	}
+	if this.schedule != nil {
+		C.jerasure_free_schedule(this.schedule)
+		this.schedule = nil
+	}
+	if this.bitmatrix != nil {
+		C.free(unsafe.Pointer(this.bitmatrix))
+		this.bitmatrix = nil
+	}
+	return nil
+}
+
+// setFinalizer closes a code when it is garbage collected, so that the
+// C memory of codes that were not closed is not leaked.
+func setFinalizer(code Coder) {
+	runtime.SetFinalizer(code, func(code Coder) { code.Close() })
+}
+
+// rowKOnes reports to jerasure_matrix_decode whether the first row of
+// the coding matrix is all ones, so that it can decode a single data
+// block by XORing the others with the first coding block.
+func (this *matrixCode) rowKOnes() C.int {
+	for _, e := range unsafe.Slice(this.matrix, this.k) {
+		if e != 1 {
+			return 0
+		}
+	}
+	return 1
+}
+
+// codingMatrix returns a copy of the m x k coding matrix, or nil if the
+// code was closed.
+func (this *matrixCode) codingMatrix() []int {
+	if this.matrix == nil {
+		return nil
+	}
+	return cToIntSlice(this.matrix, this.k*this.m)
+}
+
+// codingBitmatrix returns a copy of the mw x kw coding bitmatrix, or nil
+// if the code was closed.
+func (this *bitmatrixCode) codingBitmatrix() []int {
+	if this.bitmatrix == nil {
+		return nil
+	}
+	return cToIntSlice(this.bitmatrix, this.k*this.m*this.w*this.w)
+}
+
+// pinBlocks pins the blocks, so that jerasure can read and write them in
+// place, and returns an array of pointers to them for jerasure. The
+// blocks stay pinned until the pinner is unpinned.
+//
+// The blocks must have been checked with checkBlocks, so that none of
+// them are empty. An empty list of blocks is returned as nil.
+func pinBlocks(pinner *runtime.Pinner, blocks [][]byte) **C.char {
+	if len(blocks) == 0 {
+		return nil
+	}
+	ptrs := make([]*C.char, len(blocks))
+	for i, block := range blocks {
+		pinner.Pin(&block[0])
+		ptrs[i] = (*C.char)(unsafe.Pointer(&block[0]))
+	}
+	return &ptrs[0]
+}
+
+// intSliceToC converts a Go slice into a C int pointer array.
+//
+// Go ints are 64 bits wide and C ints 32 bits, so the slice has to be
+// copied rather than cast. An empty slice is returned as nil.
+func intSliceToC(slice []int) *C.int {
+	if len(slice) == 0 {
+		return nil
+	}
+	sliceC := make([]C.int, len(slice))
+	for i, v := range slice {
+		sliceC[i] = C.int(v)
+	}
+	return &sliceC[0]
+}
+
+// cToIntSlice copies a C int array of n elements into a Go slice.
+//
+// Elements of GF(2^32) do not fit into a C int, so they are read back
+// as unsigned, the way the Go backend holds them.
+func cToIntSlice(array *C.int, n int) []int {
+	slice := make([]int, n)
+	for i, v := range unsafe.Slice(array, n) {
+		slice[i] = int(uint32(v))
+	}
+	return slice
+}
+
+// cIntArray copies a Go slice into a C int array allocated with malloc,
+// like the matrices created by jerasure.
+func cIntArray(slice []int) *C.int {
+	array := (*C.int)(C.malloc(C.size_t(len(slice)) * C.size_t(unsafe.Sizeof(C.int(0)))))
+	elements := unsafe.Slice(array, len(slice))
+	for i, v := range slice {
+		elements[i] = C.int(v)
+	}
+	return array
+}
+
+// NewReedSolVanCode returns a Reed-Solomon code, and initialising the
+// coding matrix to a Vandermonde matrix
+func NewReedSolVanCode(k, m, w, packetSize int, bufferSize int64) (Coder, error) {
+	code := &reedSolVanCode{matrixCode{newCode(k, m, w, packetSize, bufferSize), nil}}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	initGalois(w)
+	code.matrix = C.reed_sol_vandermonde_coding_matrix(C.int(k), C.int(m), C.int(w))
+	if code.matrix == nil {
+		return nil, fmt.Errorf("%w: could not create a Vandermonde coding matrix", ErrInvalidParams)
+	}
+	setFinalizer(code)
+	return code, nil
+}
+
+// newReedSolR6Code returns a RAID-6 Reed-Solomon code. It accepts m, so
+// that it can be used by NewCode, but m has to be 2.
+func newReedSolR6Code(k, m, w, packetSize int, bufferSize int64) (Coder, error) {
+	code := &reedSolR6Code{matrixCode{newCode(k, m, w, packetSize, bufferSize), nil}}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	initGalois(w)
+	initMultby2(w)
+	code.matrix = C.reed_sol_r6_coding_matrix(C.int(k), C.int(w))
+	if code.matrix == nil {
+		return nil, fmt.Errorf("%w: could not create a RAID-6 coding matrix", ErrInvalidParams)
+	}
+	setFinalizer(code)
+	return code, nil
+}
+
+// Encode encodes the data blocks with reed_sol_r6_encode, which
+// multiplies by two with shifts instead of multiplying by the matrix.
+func (this *reedSolR6Code) Encode(data, coding [][]byte) error {
+	_, err := this.record(this.encodeStats(data, coding))
+	return err
+}
+
+// encodeStats encodes the blocks like Encode. reed_sol_r6_encode does
+// not count its work, so the statistics are always zero.
+func (this *reedSolR6Code) encodeStats(data, coding [][]byte) (Stats, error) {
+	if this.matrix == nil {
+		return Stats{}, ErrClosed
+	}
+	if err := this.checkBlocks(data, coding); err != nil {
+		return Stats{}, err
+	}
+	var pinner runtime.Pinner
+	defer pinner.Unpin()
+	dataC := pinBlocks(&pinner, data)
+	codingC := pinBlocks(&pinner, coding)
+
+	if C.reed_sol_r6_encode(C.int(this.k), C.int(this.w), dataC, codingC, C.int(this.bufferSize)) == 0 {
+		return Stats{}, fmt.Errorf("%w: word size must be 8, 16 or 32", ErrInvalidParams)
+	}
+	return Stats{}, nil
+}
+
+// NewCaucheOrigCode returns a type of bitmatrix code with both the
+// bitmatrix and schedule initialised, as selected with WithExecution.
+func NewCauchyOrigCode(k, m, w, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error) {
+	code := &cauchyOrigCode{bitmatrixCode{code: newCode(k, m, w, packetSize, bufferSize)}}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	initGalois(w)
+	matrix := C.cauchy_original_coding_matrix(C.int(k), C.int(m), C.int(w))
+	if matrix == nil {
+		return nil, fmt.Errorf("%w: could not create a Cauchy coding matrix", ErrInvalidParams)
+	}
+	code.bitmatrix = C.jerasure_matrix_to_bitmatrix(C.int(k), C.int(m), C.int(w), matrix)
+	C.free(unsafe.Pointer(matrix))
+	if err := code.setExecution(opts); err != nil {
+		code.Close()
+		return nil, err
+	}
+	setFinalizer(code)
+	return code, nil
+}
+
+// NewCaucheGoodCode returns a type of bitmatrix code with both the
+// bitmatrix and schedule initialised, as selected with WithExecution.
+func NewCauchyGoodCode(k, m, w, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error) {
+	code := &cauchyGoodCode{bitmatrixCode{code: newCode(k, m, w, packetSize, bufferSize)}}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	initGalois(w)
+	matrix := C.cauchy_good_general_coding_matrix(C.int(k), C.int(m), C.int(w))
+	if matrix == nil {
+		return nil, fmt.Errorf("%w: could not create a Cauchy coding matrix", ErrInvalidParams)
+	}
+	code.bitmatrix = C.jerasure_matrix_to_bitmatrix(C.int(k), C.int(m), C.int(w), matrix)
+	C.free(unsafe.Pointer(matrix))
+	if err := code.setExecution(opts); err != nil {
+		code.Close()
+		return nil, err
+	}
+	setFinalizer(code)
+	return code, nil
+}
+
+// NewCauchyXYCode returns a Cauchy code whose coding matrix is built from
+// the given X and Y sets, with element i,j equal to 1/(x[i]+y[j]). x has
+// to hold m and y k distinct elements of GF(2^w), with no element in
+// both. If improve is set, the matrix is scaled to reduce the number of
+// XORs, the way NewCauchyGoodCode does.
+//
+// The sets are not recorded in stripe manifests, so stripes encoded with
+// the code have to be decoded with Decode and the same code.
+func NewCauchyXYCode(k, m, w int, x, y []int, improve bool, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error) {
+	code := &cauchyXYCode{bitmatrixCode{code: newCode(k, m, w, packetSize, bufferSize)}, append([]int(nil), x...), append([]int(nil), y...)}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	initGalois(w)
+	matrix := C.cauchy_xy_coding_matrix(C.int(k), C.int(m), C.int(w), intSliceToC(code.x), intSliceToC(code.y))
+	if matrix == nil {
+		return nil, fmt.Errorf("%w: could not create a Cauchy coding matrix", ErrInvalidParams)
+	}
+	if improve {
+		C.cauchy_improve_coding_matrix(C.int(k), C.int(m), C.int(w), matrix)
+	}
+	code.bitmatrix = C.jerasure_matrix_to_bitmatrix(C.int(k), C.int(m), C.int(w), matrix)
+	C.free(unsafe.Pointer(matrix))
+	if err := code.setExecution(opts); err != nil {
+		code.Close()
+		return nil, err
+	}
+	setFinalizer(code)
+	return code, nil
+}
+
+// NewLiberationCode returns a type of bitmatrix code with both the
+// bitmatrix and schedule initialised, as selected with WithExecution.
+func NewLiberationCode(k, m, w, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error) {
+	code := &liberationCode{bitmatrixCode{code: newCode(k, m, w, packetSize, bufferSize)}}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	code.bitmatrix = C.liberation_coding_bitmatrix(C.int(k), C.int(w))
+	if code.bitmatrix == nil {
+		return nil, fmt.Errorf("%w: could not create a Liberation coding bitmatrix", ErrInvalidParams)
+	}
+	if err := code.setExecution(opts); err != nil {
+		code.Close()
+		return nil, err
+	}
+	setFinalizer(code)
+	return code, nil
+}
+
+// NewBlaumRothCode returns a type of bitmatrix code with both the
+// bitmatrix and schedule initialised, as selected with WithExecution.
+func NewBlaumRothCode(k, m, w, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error) {
+	code := &blaumRothCode{bitmatrixCode{code: newCode(k, m, w, packetSize, bufferSize)}}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	code.bitmatrix = C.blaum_roth_coding_bitmatrix(C.int(k), C.int(w))
+	if code.bitmatrix == nil {
+		return nil, fmt.Errorf("%w: could not create a Blaum-Roth coding bitmatrix", ErrInvalidParams)
+	}
+	if err := code.setExecution(opts); err != nil {
+		code.Close()
+		return nil, err
+	}
+	setFinalizer(code)
+	return code, nil
+}
+
+// NewLiber8tionCode returns a type of bitmatrix code with both the
+// bitmatrix and schedule initialised, as selected with WithExecution.
+func NewLiber8tionCode(k, m, w, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error) {
+	code := &liber8tionCode{bitmatrixCode{code: newCode(k, m, w, packetSize, bufferSize)}}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	code.bitmatrix = C.liber8tion_coding_bitmatrix(C.int(k))
+	if code.bitmatrix == nil {
+		return nil, fmt.Errorf("%w: could not create a Liber8tion coding bitmatrix", ErrInvalidParams)
+	}
+	if err := code.setExecution(opts); err != nil {
+		code.Close()
+		return nil, err
+	}
+	setFinalizer(code)
+	return code, nil
+}
+
+// NewMatrixCode returns a code that encodes with the given m x k coding
+// matrix over GF(2^w), like the Reed-Solomon codes. The matrix has to be
+// MDS: every k rows of the identity on top of the coding matrix have to
+// be invertible, so that any k blocks of a stripe can be decoded.
+//
+// The matrix is not recorded in stripe manifests, so stripes encoded
+// with the code have to be decoded with Decode and the same code.
+func NewMatrixCode(k, m, w int, matrix [][]int, packetSize int, bufferSize int64) (Coder, error) {
+	elements, err := flattenCodingMatrix(matrix, m, k)
+	if err != nil {
+		return nil, err
+	}
+	code := &customMatrixCode{matrixCode{newCode(k, m, w, packetSize, bufferSize), nil}, elements}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	initGalois(w)
+	code.matrix = cIntArray(elements)
+	setFinalizer(code)
+	return code, nil
+}
+
+// NewBitmatrixCode returns a code that encodes with the given mw x kw
+// coding bitmatrix, like the Cauchy and Liberation codes. The bitmatrix
+// has to be MDS, like the matrix of NewMatrixCode.
+//
+// The bitmatrix is not recorded in stripe manifests, so stripes encoded
+// with the code have to be decoded with Decode and the same code.
+func NewBitmatrixCode(k, m, w int, bitmatrix [][]int, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error) {
+	bits, err := flattenCodingMatrix(bitmatrix, m*w, k*w)
+	if err != nil {
+		return nil, err
+	}
+	code := &customBitmatrixCode{bitmatrixCode{code: newCode(k, m, w, packetSize, bufferSize)}, bits}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	code.bitmatrix = cIntArray(bits)
+	if err := code.setExecution(opts); err != nil {
+		code.Close()
+		return nil, err
+	}
+	setFinalizer(code)
+	return code, nil
+}
+
+// galoisMu serialises the creation of the galois field tables.
+var galoisMu sync.Mutex
+
+// initGalois creates the galois field tables for w. Jerasure creates
+// them lazily on first use, which is not safe when codes are used from
+// several goroutines, so the constructors create them up front.
+func initGalois(w int) {
+	galoisMu.Lock()
+	defer galoisMu.Unlock()
+	C.galois_single_multiply(1, 1, C.int(w))
+}
+
+// initMultby2 sets up the masks of reed_sol_r6_encode for w, which it
+// would otherwise create lazily on first use, like the galois field
+// tables.
+func initMultby2(w int) {
+	galoisMu.Lock()
+	defer galoisMu.Unlock()
+
+	var region [8]byte
+	regionC := (*C.char)(unsafe.Pointer(&region[0]))
+	switch w {
+	case 8:
+		C.reed_sol_galois_w08_region_multby_2(regionC, C.int(len(region)))
+	case 16:
+		C.reed_sol_galois_w16_region_multby_2(regionC, C.int(len(region)))
+	case 32:
+		C.reed_sol_galois_w32_region_multby_2(regionC, C.int(len(region)))
+	}
+}
@@ -0,0 +1,171 @@
+//go:build linux && cgo && !purego
+// +build linux,cgo,!purego
+
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// embeddedBitmatrixCode returns the bitmatrixCode of an m = 2 code.
+func embeddedBitmatrixCode(code Coder) *bitmatrixCode {
+	switch c := code.(type) {
+	case *liberationCode:
+		return &c.bitmatrixCode
+	case *blaumRothCode:
+		return &c.bitmatrixCode
+	case *liber8tionCode:
+		return &c.bitmatrixCode
+	case *cauchyOrigCode:
+		return &c.bitmatrixCode
+	case *cauchyGoodCode:
+		return &c.bitmatrixCode
+	}
+	return nil
+}
+
+// TestSmartSchedule ensures that a smart schedule saves work over a dumb
+// one on a dense Cauchy bitmatrix. A dumb schedule does as much work as
+// the bitmatrix itself.
+func TestSmartSchedule(t *testing.T) {
+	k, m, w, packetSize := 6, 2, 8, 16
+	bufferSize := int64(sizeInt * k * w * packetSize)
+	data := allocateBuffers(k, bufferSize)
+	coding := allocateBuffers(m, bufferSize)
+
+	stats := make(map[Execution]Stats)
+	for _, execution := range []Execution{SmartSchedule, DumbSchedule, NoSchedule} {
+		code, err := NewCauchyOrigCode(k, m, w, packetSize, bufferSize, WithExecution(execution))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats[execution], err = EncodeStats(code, data, coding); err != nil {
+			t.Fatal(err)
+		}
+		code.Close()
+	}
+	if stats[DumbSchedule] != stats[NoSchedule] {
+		t.Errorf("dumb schedule did %+v, bitmatrix did %+v", stats[DumbSchedule], stats[NoSchedule])
+	}
+	if stats[SmartSchedule].XORBytes >= stats[DumbSchedule].XORBytes {
+		t.Errorf("smart schedule XORed %d bytes, dumb schedule %d", stats[SmartSchedule].XORBytes, stats[DumbSchedule].XORBytes)
+	}
+}
+
+// TestScheduleCache decodes every erasure of one and two blocks of the
+// m = 2 bitmatrix codes from their cached decoding schedules.
+func TestScheduleCache(t *testing.T) {
+	k, m, packetSize := 6, 2, 16
+	for technique, w := range map[string]int{Liberation: 7, BlaumRoth: 6, Liber8tion: 8, CauchyOrig: 8, CauchyGood: 8} {
+		code, err := NewCode(technique, k, m, w, packetSize, int64(sizeInt*k*w*packetSize))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if embeddedBitmatrixCode(code).scheduleCache() == nil {
+			t.Fatalf("%s: expected a schedule cache", technique)
+		}
+
+		data := allocateBuffers(k, code.Buffersize())
+		coding := allocateBuffers(m, code.Buffersize())
+		for i := range data {
+			rand.Read(data[i])
+		}
+		if err = code.Encode(data, coding); err != nil {
+			t.Fatal(err)
+		}
+
+		// Both orders of every pair of blocks are decoded, as well as
+		// every block on its own
+		for i := 0; i < k+m; i++ {
+			for j := 0; j < k+m; j++ {
+				erasures := []int{i, j, -1}
+				if i == j {
+					erasures = []int{i, -1}
+				}
+				decodedData := allocateBuffers(k, code.Buffersize())
+				decodedCoding := allocateBuffers(m, code.Buffersize())
+				for id := 0; id < k+m; id++ {
+					if id == i || id == j {
+						continue
+					}
+					if id < k {
+						copy(decodedData[id], data[id])
+					} else {
+						copy(decodedCoding[id-k], coding[id-k])
+					}
+				}
+
+				if err = code.Decode(decodedData, decodedCoding, erasures); err != nil {
+					t.Fatalf("%s: erasures %v: %v", technique, erasures, err)
+				}
+				for id := range data {
+					if !bytes.Equal(decodedData[id], data[id]) {
+						t.Errorf("%s: erasures %v: data block %d differs", technique, erasures, id)
+					}
+				}
+				for id := range coding {
+					if !bytes.Equal(decodedCoding[id], coding[id]) {
+						t.Errorf("%s: erasures %v: coding block %d differs", technique, erasures, id)
+					}
+				}
+			}
+		}
+		code.Close()
+	}
+}
+
+// BenchmarkScheduleCache compares decoding the m = 2 bitmatrix codes with
+// their cached decoding schedules to creating a schedule on every call.
+func BenchmarkScheduleCache(b *testing.B) {
+	k, m, packetSize := 6, 2, 1024
+	for technique, w := range map[string]int{Liberation: 7, BlaumRoth: 6, Liber8tion: 8, CauchyGood: 8} {
+		code, err := NewCode(technique, k, m, w, packetSize, int64(sizeInt*k*w*packetSize))
+		if err != nil {
+			b.Fatal(err)
+		}
+		bitmatrix := embeddedBitmatrixCode(code)
+
+		data := allocateBuffers(k, code.Buffersize())
+		coding := allocateBuffers(m, code.Buffersize())
+		for i := range data {
+			rand.Read(data[i])
+		}
+		if err = code.Encode(data, coding); err != nil {
+			b.Fatal(err)
+		}
+		erasures := []int{1, 4, -1}
+
+		b.Run(technique+"/cache", func(b *testing.B) {
+			b.SetBytes(int64(k) * code.Buffersize())
+			for i := 0; i < b.N; i++ {
+				if _, err := bitmatrix.decode(data, coding, erasures, bitmatrix.scheduleCache()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(technique+"/lazy", func(b *testing.B) {
+			b.SetBytes(int64(k) * code.Buffersize())
+			for i := 0; i < b.N; i++ {
+				if _, err := bitmatrix.decode(data, coding, erasures, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+//go:build !linux || !cgo || purego
+// +build !linux !cgo purego
+
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"fmt"
+)
+
+// Without the Jerasure library, the codes are encoded and decoded in Go.
+type (
+	matrixCode    = goMatrixCode
+	bitmatrixCode = goBitmatrixCode
+)
+
+// NewReedSolVanCode returns a Reed-Solomon code, and initialising the
+// coding matrix to a Vandermonde matrix
+func NewReedSolVanCode(k, m, w, packetSize int, bufferSize int64) (Coder, error) {
+	code := &reedSolVanCode{matrixCode{newCode(k, m, w, packetSize, bufferSize), nil}}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	code.matrix = reedSolVandermondeMatrix(k, m, w)
+	if code.matrix == nil {
+		return nil, fmt.Errorf("%w: could not create a Vandermonde coding matrix", ErrInvalidParams)
+	}
+	return code, nil
+}
+
+// newReedSolR6Code returns a RAID-6 Reed-Solomon code. It accepts m, so
+// that it can be used by NewCode, but m has to be 2.
+func newReedSolR6Code(k, m, w, packetSize int, bufferSize int64) (Coder, error) {
+	code := &reedSolR6Code{matrixCode{newCode(k, m, w, packetSize, bufferSize), nil}}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	code.matrix = reedSolR6Matrix(k, w)
+	return code, nil
+}
+
+// Encode encodes the data blocks the way reed_sol_r6_encode does.
+func (this *reedSolR6Code) Encode(data, coding [][]byte) error {
+	_, err := this.record(this.encodeStats(data, coding))
+	return err
+}
+
+// encodeStats encodes the blocks like Encode. Like jerasure, the RAID-6
+// encoder does not count its work.
+func (this *reedSolR6Code) encodeStats(data, coding [][]byte) (Stats, error) {
+	if this.matrix == nil {
+		return Stats{}, ErrClosed
+	}
+	if err := this.checkBlocks(data, coding); err != nil {
+		return Stats{}, err
+	}
+	reedSolR6Encode(this.w, data, coding)
+	return Stats{}, nil
+}
+
+// NewCaucheOrigCode returns a type of bitmatrix code with the bitmatrix
+// initialised.
+func NewCauchyOrigCode(k, m, w, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error) {
+	code := &cauchyOrigCode{bitmatrixCode{newCode(k, m, w, packetSize, bufferSize), nil}}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	if _, err := newCodeOptions(opts); err != nil {
+		return nil, err
+	}
+	matrix := cauchyOriginalMatrix(k, m, w)
+	if matrix == nil {
+		return nil, fmt.Errorf("%w: could not create a Cauchy coding matrix", ErrInvalidParams)
+	}
+	code.bitmatrix = matrixToBitmatrix(k, m, w, matrix)
+	return code, nil
+}
+
+// NewCaucheGoodCode returns a type of bitmatrix code with the bitmatrix
+// initialised.
+func NewCauchyGoodCode(k, m, w, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error) {
+	code := &cauchyGoodCode{bitmatrixCode{newCode(k, m, w, packetSize, bufferSize), nil}}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	if _, err := newCodeOptions(opts); err != nil {
+		return nil, err
+	}
+	matrix := cauchyGoodMatrix(k, m, w)
+	if matrix == nil {
+		return nil, fmt.Errorf("%w: could not create a Cauchy coding matrix", ErrInvalidParams)
+	}
+	code.bitmatrix = matrixToBitmatrix(k, m, w, matrix)
+	return code, nil
+}
+
+// NewCauchyXYCode returns a Cauchy code whose coding matrix is built from
+// the given X and Y sets, with element i,j equal to 1/(x[i]+y[j]). x has
+// to hold m and y k distinct elements of GF(2^w), with no element in
+// both. If improve is set, the matrix is scaled to reduce the number of
+// XORs, the way NewCauchyGoodCode does.
+//
+// The sets are not recorded in stripe manifests, so stripes encoded with
+// the code have to be decoded with Decode and the same code.
+func NewCauchyXYCode(k, m, w int, x, y []int, improve bool, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error) {
+	code := &cauchyXYCode{bitmatrixCode{newCode(k, m, w, packetSize, bufferSize), nil}, append([]int(nil), x...), append([]int(nil), y...)}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	if _, err := newCodeOptions(opts); err != nil {
+		return nil, err
+	}
+	matrix := cauchyXYMatrix(k, m, w, code.x, code.y)
+	if improve {
+		cauchyImproveMatrix(k, m, w, matrix)
+	}
+	code.bitmatrix = matrixToBitmatrix(k, m, w, matrix)
+	return code, nil
+}
+
+// NewLiberationCode returns a type of bitmatrix code with the bitmatrix
+// initialised.
+func NewLiberationCode(k, m, w, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error) {
+	code := &liberationCode{bitmatrixCode{newCode(k, m, w, packetSize, bufferSize), nil}}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	if _, err := newCodeOptions(opts); err != nil {
+		return nil, err
+	}
+	code.bitmatrix = liberationBitmatrix(k, w)
+	if code.bitmatrix == nil {
+		return nil, fmt.Errorf("%w: could not create a Liberation coding bitmatrix", ErrInvalidParams)
+	}
+	return code, nil
+}
+
+// NewBlaumRothCode returns a type of bitmatrix code with the bitmatrix
+// initialised.
+func NewBlaumRothCode(k, m, w, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error) {
+	code := &blaumRothCode{bitmatrixCode{newCode(k, m, w, packetSize, bufferSize), nil}}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	if _, err := newCodeOptions(opts); err != nil {
+		return nil, err
+	}
+	code.bitmatrix = blaumRothBitmatrix(k, w)
+	if code.bitmatrix == nil {
+		return nil, fmt.Errorf("%w: could not create a Blaum-Roth coding bitmatrix", ErrInvalidParams)
+	}
+	return code, nil
+}
+
+// NewLiber8tionCode returns a type of bitmatrix code with the bitmatrix
+// initialised.
+func NewLiber8tionCode(k, m, w, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error) {
+	code := &liber8tionCode{bitmatrixCode{newCode(k, m, w, packetSize, bufferSize), nil}}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	if _, err := newCodeOptions(opts); err != nil {
+		return nil, err
+	}
+	code.bitmatrix = liber8tionBitmatrix(k)
+	if code.bitmatrix == nil {
+		return nil, fmt.Errorf("%w: could not create a Liber8tion coding bitmatrix", ErrInvalidParams)
+	}
+	return code, nil
+}
+
+// NewMatrixCode returns a code that encodes with the given m x k coding
+// matrix over GF(2^w), like the Reed-Solomon codes. The matrix has to be
+// MDS: every k rows of the identity on top of the coding matrix have to
+// be invertible, so that any k blocks of a stripe can be decoded.
+//
+// The matrix is not recorded in stripe manifests, so stripes encoded
+// with the code have to be decoded with Decode and the same code.
+func NewMatrixCode(k, m, w int, matrix [][]int, packetSize int, bufferSize int64) (Coder, error) {
+	elements, err := flattenCodingMatrix(matrix, m, k)
+	if err != nil {
+		return nil, err
+	}
+	code := &customMatrixCode{matrixCode{newCode(k, m, w, packetSize, bufferSize), nil}, elements}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	code.matrix = elements
+	return code, nil
+}
+
+// NewBitmatrixCode returns a code that encodes with the given mw x kw
+// coding bitmatrix, like the Cauchy and Liberation codes. The bitmatrix
+// has to be MDS, like the matrix of NewMatrixCode.
+//
+// The bitmatrix is not recorded in stripe manifests, so stripes encoded
+// with the code have to be decoded with Decode and the same code.
+func NewBitmatrixCode(k, m, w int, bitmatrix [][]int, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error) {
+	bits, err := flattenCodingMatrix(bitmatrix, m*w, k*w)
+	if err != nil {
+		return nil, err
+	}
+	code := &customBitmatrixCode{bitmatrixCode{newCode(k, m, w, packetSize, bufferSize), nil}, bits}
+	if err := code.ValidateCode(); err != nil {
+		return nil, err
+	}
+	if _, err := newCodeOptions(opts); err != nil {
+		return nil, err
+	}
+	code.bitmatrix = bits
+	return code, nil
+}
@@ -0,0 +1,446 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestInvalidParams(t *testing.T) {
+	constructors := map[string]func() (Coder, error){
+		"reedSolVan w":    func() (Coder, error) { return NewReedSolVanCode(6, 2, 7, 0, 0) },
+		"reedSolVan k":    func() (Coder, error) { return NewReedSolVanCode(0, 2, 8, 0, 0) },
+		"reedSolR6 w":     func() (Coder, error) { return NewReedSolR6Code(6, 7, 0, 0) },
+		"reedSolR6 m":     func() (Coder, error) { return NewCode(ReedSolR6, 6, 3, 8, 0, 0) },
+		"cauchyOrig k+m":  func() (Coder, error) { return NewCauchyOrigCode(6, 3, 3, 8, 0) },
+		"cauchyGood pkt":  func() (Coder, error) { return NewCauchyGoodCode(6, 2, 8, 0, 0) },
+		"liberation w":    func() (Coder, error) { return NewLiberationCode(6, 2, 8, 128, 0) },
+		"liberation k>w":  func() (Coder, error) { return NewLiberationCode(8, 2, 7, 128, 0) },
+		"blaumRoth w":     func() (Coder, error) { return NewBlaumRothCode(6, 2, 7, 128, 0) },
+		"liber8tion m":    func() (Coder, error) { return NewLiber8tionCode(6, 3, 8, 128, 0) },
+		"liber8tion size": func() (Coder, error) { return NewLiber8tionCode(6, 2, 8, 128, -1) },
+
+		// A code without coding blocks is rejected by every constructor
+		"reedSolVan m=0": func() (Coder, error) { return NewReedSolVanCode(4, 0, 8, 0, 0) },
+		"reedSolR6 m=0":  func() (Coder, error) { return NewCode(ReedSolR6, 4, 0, 8, 0, 0) },
+		"cauchyOrig m=0": func() (Coder, error) { return NewCauchyOrigCode(4, 0, 8, 8, 2048) },
+		"cauchyGood m=0": func() (Coder, error) { return NewCauchyGoodCode(4, 0, 8, 8, 2048) },
+		"cauchyXY m=0":   func() (Coder, error) { return NewCauchyXYCode(4, 0, 8, nil, []int{0, 1, 2, 3}, false, 8, 2048) },
+		"liberation m=0": func() (Coder, error) { return NewLiberationCode(4, 0, 7, 8, 0) },
+		"blaumRoth m=0":  func() (Coder, error) { return NewBlaumRothCode(4, 0, 6, 8, 0) },
+		"liber8tion m=0": func() (Coder, error) { return NewLiber8tionCode(4, 0, 8, 8, 0) },
+		"matrix m=0":     func() (Coder, error) { return NewMatrixCode(4, 0, 8, nil, 0, 0) },
+		"bitmatrix m=0":  func() (Coder, error) { return NewBitmatrixCode(4, 0, 8, nil, 8, 0) },
+	}
+
+	for name, constructor := range constructors {
+		code, err := constructor()
+		if !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("%s: expected ErrInvalidParams, got %v", name, err)
+		}
+		if code != nil {
+			t.Errorf("%s: expected no code to be returned", name)
+		}
+	}
+}
+
+func TestReedSolR6(t *testing.T) {
+	k, m := 6, 2
+	for _, w := range []int{8, 16, 32} {
+		bufferSize := int64(sizeInt * k * w * 4)
+		code, err := NewReedSolR6Code(k, w, 0, bufferSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code.M() != m || code.Technique() != ReedSolR6 {
+			t.Errorf("unexpected m=%d and technique %q", code.M(), code.Technique())
+		}
+
+		data := allocateBuffers(k, bufferSize)
+		for i := range data {
+			rand.Read(data[i])
+		}
+		coding := allocateBuffers(m, bufferSize)
+		if err = code.Encode(data, coding); err != nil {
+			t.Fatal(err)
+		}
+
+		// The fast encoder has to agree with the coding matrix
+		matrixCoding := allocateBuffers(m, bufferSize)
+		if err = code.(*reedSolR6Code).matrixCode.Encode(data, matrixCoding); err != nil {
+			t.Fatal(err)
+		}
+		for i := range coding {
+			if !bytes.Equal(coding[i], matrixCoding[i]) {
+				t.Errorf("w=%d: coding block %d differs from the matrix encoding", w, i)
+			}
+		}
+
+		decoded := allocateBuffers(k, bufferSize)
+		for i := 2; i < k; i++ {
+			copy(decoded[i], data[i])
+		}
+		if err = code.Decode(decoded, coding, []int{0, 1, -1}); err != nil {
+			t.Fatal(err)
+		}
+		for i := range data {
+			if !bytes.Equal(decoded[i], data[i]) {
+				t.Errorf("w=%d: data block %d was not decoded", w, i)
+			}
+		}
+	}
+}
+
+func TestCauchyXY(t *testing.T) {
+	k, m, w, packetSize := 5, 3, 4, 8
+	bufferSize := int64(sizeInt * k * w * packetSize)
+	x := []int{0, 1, 2}
+	y := []int{3, 4, 5, 6, 7}
+
+	data := allocateBuffers(k, bufferSize)
+	for i := range data {
+		rand.Read(data[i])
+	}
+
+	// The original and good Cauchy codes use these sets
+	for _, improve := range []bool{false, true} {
+		code, err := NewCauchyXYCode(k, m, w, x, y, improve, packetSize, bufferSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		constructor := NewCauchyOrigCode
+		if improve {
+			constructor = NewCauchyGoodCode
+		}
+		reference, err := constructor(k, m, w, packetSize, bufferSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		coding := allocateBuffers(m, bufferSize)
+		if err = code.Encode(data, coding); err != nil {
+			t.Fatal(err)
+		}
+		referenceCoding := allocateBuffers(m, bufferSize)
+		if err = reference.Encode(data, referenceCoding); err != nil {
+			t.Fatal(err)
+		}
+		for i := range coding {
+			if !bytes.Equal(coding[i], referenceCoding[i]) {
+				t.Errorf("improve=%v: coding block %d differs from %s", improve, i, reference.Technique())
+			}
+		}
+
+		decoded := allocateBuffers(k, bufferSize)
+		for i := m; i < k; i++ {
+			copy(decoded[i], data[i])
+		}
+		if err = code.Decode(decoded, coding, []int{0, 1, 2, -1}); err != nil {
+			t.Fatal(err)
+		}
+		for i := range data {
+			if !bytes.Equal(decoded[i], data[i]) {
+				t.Errorf("improve=%v: data block %d was not decoded", improve, i)
+			}
+		}
+	}
+
+	invalid := map[string][][]int{
+		"overlapping": {{0, 1, 2}, {2, 4, 5, 6, 7}},
+		"repeated":    {{0, 1, 1}, {3, 4, 5, 6, 7}},
+		"outside":     {{0, 1, 16}, {3, 4, 5, 6, 7}},
+		"negative":    {{0, 1, -1}, {3, 4, 5, 6, 7}},
+		"short":       {{0, 1}, {3, 4, 5, 6, 7}},
+		"long":        {{0, 1, 2, 8}, {3, 4, 5, 6, 7}},
+		"no x":        {nil, {3, 4, 5, 6, 7}},
+		"no y":        {{0, 1, 2}, nil},
+	}
+	for name, sets := range invalid {
+		if _, err := NewCauchyXYCode(k, m, w, sets[0], sets[1], false, packetSize, bufferSize); !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("%s: expected ErrInvalidParams, got %v", name, err)
+		}
+	}
+}
+
+func TestCustomCodes(t *testing.T) {
+	k, m, w, packetSize := 4, 2, 8, 8
+	bufferSize := int64(sizeInt * k * w * packetSize)
+
+	data := allocateBuffers(k, bufferSize)
+	for i := range data {
+		rand.Read(data[i])
+	}
+
+	reedSolVan, err := NewReedSolVanCode(k, m, w, packetSize, bufferSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cauchyGood, err := NewCauchyGoodCode(k, m, w, packetSize, bufferSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vandermonde, _ := CodingMatrix(reedSolVan)
+	cauchy, _ := NewMatrix(unflatten(cauchyOriginalMatrix(k, m, w), m, k), w)
+	bitmatrix, _ := CodingBitMatrix(cauchyGood)
+
+	codes := []struct {
+		name      string
+		matrix    [][]int
+		bitmatrix bool
+		reference Coder
+	}{
+		{"vandermonde", vandermonde.Elements(), false, reedSolVan},
+		// The first row of the Cauchy matrix is not all ones
+		{"cauchy", cauchy.Elements(), false, nil},
+		{"bitmatrix", bitmatrix.Elements(), true, cauchyGood},
+	}
+	for _, c := range codes {
+		var code Coder
+		if c.bitmatrix {
+			code, err = NewBitmatrixCode(k, m, w, c.matrix, packetSize, bufferSize)
+		} else {
+			code, err = NewMatrixCode(k, m, w, c.matrix, packetSize, bufferSize)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+
+		coding := allocateBuffers(m, bufferSize)
+		if err = code.Encode(data, coding); err != nil {
+			t.Fatal(err)
+		}
+		if c.reference != nil {
+			referenceCoding := allocateBuffers(m, bufferSize)
+			if err = c.reference.Encode(data, referenceCoding); err != nil {
+				t.Fatal(err)
+			}
+			for i := range coding {
+				if !bytes.Equal(coding[i], referenceCoding[i]) {
+					t.Errorf("%s: coding block %d differs from %s", c.name, i, c.reference.Technique())
+				}
+			}
+		}
+
+		// Every pair of blocks has to be recoverable
+		forEachSubset(k+m, m, func(ids []int) bool {
+			decodedData := allocateBuffers(k, bufferSize)
+			decodedCoding := allocateBuffers(m, bufferSize)
+			for i := range data {
+				copy(decodedData[i], data[i])
+			}
+			for i := range coding {
+				copy(decodedCoding[i], coding[i])
+			}
+			for _, id := range ids {
+				if id < k {
+					zero(decodedData[id])
+				} else {
+					zero(decodedCoding[id-k])
+				}
+			}
+			if err := code.Decode(decodedData, decodedCoding, append(append([]int(nil), ids...), -1)); err != nil {
+				t.Errorf("%s: erasures %v: %v", c.name, ids, err)
+				return true
+			}
+			for i := range data {
+				if !bytes.Equal(decodedData[i], data[i]) {
+					t.Errorf("%s: erasures %v: data block %d was not decoded", c.name, ids, i)
+				}
+			}
+			for i := range coding {
+				if !bytes.Equal(decodedCoding[i], coding[i]) {
+					t.Errorf("%s: erasures %v: coding block %d was not decoded", c.name, ids, i)
+				}
+			}
+			return true
+		})
+	}
+
+	invalid := map[string]func() (Coder, error){
+		"not MDS":      func() (Coder, error) { return NewMatrixCode(2, 2, 8, [][]int{{1, 1}, {1, 0}}, 0, 0) },
+		"rows":         func() (Coder, error) { return NewMatrixCode(2, 2, 8, [][]int{{1, 1}}, 0, 0) },
+		"ragged":       func() (Coder, error) { return NewMatrixCode(2, 2, 8, [][]int{{1, 1}, {1}}, 0, 0) },
+		"w":            func() (Coder, error) { return NewMatrixCode(2, 1, 7, [][]int{{1, 1}}, 0, 0) },
+		"element":      func() (Coder, error) { return NewMatrixCode(2, 1, 8, [][]int{{1, 256}}, 0, 0) },
+		"bit":          func() (Coder, error) { return NewBitmatrixCode(1, 1, 1, [][]int{{2}}, 8, 0) },
+		"bits not MDS": func() (Coder, error) { return NewBitmatrixCode(1, 1, 2, [][]int{{1, 1}, {1, 1}}, 8, 0) },
+		"bit packet":   func() (Coder, error) { return NewBitmatrixCode(1, 1, 1, [][]int{{1}}, 0, 0) },
+	}
+	for name, constructor := range invalid {
+		if _, err := constructor(); !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("%s: expected ErrInvalidParams, got %v", name, err)
+		}
+	}
+}
+
+func TestClose(t *testing.T) {
+	k, packetSize := 4, 8
+
+	for technique := range constructors {
+		w := map[string]int{Liberation: 7, BlaumRoth: 6}[technique]
+		if w == 0 {
+			w = 8
+		}
+		bufferSize := int64(sizeInt * k * w * packetSize)
+		code, err := NewCode(technique, k, 2, w, packetSize, bufferSize)
+		if err != nil {
+			t.Fatalf("%s: %v", technique, err)
+		}
+		if err = code.Close(); err != nil {
+			t.Fatalf("%s: %v", technique, err)
+		}
+
+		data := allocateBuffers(k, bufferSize)
+		coding := allocateBuffers(2, bufferSize)
+		if err = code.Encode(data, coding); !errors.Is(err, ErrClosed) {
+			t.Errorf("%s: expected ErrClosed from Encode, got %v", technique, err)
+		}
+		if err = code.Decode(data, coding, []int{0, -1}); !errors.Is(err, ErrClosed) {
+			t.Errorf("%s: expected ErrClosed from Decode, got %v", technique, err)
+		}
+		if _, err = CodingBitMatrix(code); !errors.Is(err, ErrClosed) {
+			t.Errorf("%s: expected ErrClosed from CodingBitMatrix, got %v", technique, err)
+		}
+	}
+}
+
+func TestCheckFileSize(t *testing.T) {
+	// The buffer size of a Liberation code with k=6, w=7 and a packet
+	// size of 128 must be a multiple of the size of k*w*packetsize ints
+	multiple := int64(sizeInt) * 6 * 7 * 128
+	bufferSize := 6 * multiple
+
+	code, err := NewLiberationCode(6, 2, 7, 128, bufferSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = code.CheckFileSize(bufferSize * 2); err != nil {
+		t.Errorf("expected aligned file size to pass, got %v", err)
+	}
+	if err = code.CheckFileSize(bufferSize + 1); err != nil {
+		t.Errorf("expected unaligned file size to be padded, got %v", err)
+	}
+
+	code, err = NewLiberationCode(6, 2, 7, 128, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = code.CheckFileSize(bufferSize); !errors.Is(err, ErrMisalignedBuffer) {
+		t.Errorf("expected ErrMisalignedBuffer, got %v", err)
+	}
+
+	code, err = NewLiberationCode(6, 2, 7, 128, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = code.CheckFileSize(1000); err != nil {
+		t.Fatal(err)
+	}
+	if code.Buffersize() != multiple {
+		t.Errorf("expected the buffer size to be padded to %d, got %d", multiple, code.Buffersize())
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	k, m := 6, 2
+	buffersize := int64(258048)
+	code, err := NewLiberationCode(k, m, 7, 128, buffersize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := allocateBuffers(k, buffersize)
+	coding := allocateBuffers(m, buffersize)
+
+	if err = code.Decode(data, coding, []int{0, 1, 2, -1}); !errors.Is(err, ErrTooManyErasures) {
+		t.Errorf("expected ErrTooManyErasures, got %v", err)
+	}
+	if err = code.Decode(data, coding, []int{0}); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("expected ErrInvalidParams for an unterminated erasure list, got %v", err)
+	}
+	if err = code.Encode(nil, coding); !errors.Is(err, ErrNoData) {
+		t.Errorf("expected ErrNoData, got %v", err)
+	}
+	if err = code.Encode(allocateBuffers(k, 100), coding); !errors.Is(err, ErrMisalignedBuffer) {
+		t.Errorf("expected ErrMisalignedBuffer, got %v", err)
+	}
+	if err = Decode("testfiles/missing", code); !errors.Is(err, ErrTooManyErasures) {
+		t.Errorf("expected ErrTooManyErasures, got %v", err)
+	}
+}
+
+// benchmarkCodes returns codes with k = 6 and m = 2 that work on buffers
+// of about 1 MiB per block.
+func benchmarkCodes(b *testing.B) map[string]Coder {
+	k, m, packetSize := 6, 2, 1024
+	codes := make(map[string]Coder)
+	for technique, w := range map[string]int{ReedSolVan: 8, CauchyGood: 8, Liberation: 7} {
+		code, err := NewCode(technique, k, m, w, packetSize, int64(sizeInt*k*w*packetSize*3))
+		if err != nil {
+			b.Fatal(err)
+		}
+		codes[technique] = code
+	}
+	return codes
+}
+
+func BenchmarkEncode(b *testing.B) {
+	for technique, code := range benchmarkCodes(b) {
+		b.Run(technique, func(b *testing.B) {
+			data := allocateBuffers(code.K(), code.Buffersize())
+			coding := allocateBuffers(code.M(), code.Buffersize())
+			for i := range data {
+				rand.Read(data[i])
+			}
+
+			b.SetBytes(int64(code.K()) * code.Buffersize())
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := code.Encode(data, coding); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	for technique, code := range benchmarkCodes(b) {
+		b.Run(technique, func(b *testing.B) {
+			data := allocateBuffers(code.K(), code.Buffersize())
+			coding := allocateBuffers(code.M(), code.Buffersize())
+			for i := range data {
+				rand.Read(data[i])
+			}
+			if err := code.Encode(data, coding); err != nil {
+				b.Fatal(err)
+			}
+
+			// Decode two data blocks, which are decoded in place
+			b.SetBytes(int64(code.K()) * code.Buffersize())
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := code.Decode(data, coding, []int{0, 1, -1}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -1,5 +1,3 @@
-// +build linux
-
 //   Copyright 2013 Vastech SA (PTY) LTD
 //
 //   Licensed under the Apache License, Version 2.0 (the "License");
@@ -16,162 +14,321 @@
 
 package goerasure
 
-// #include "jerasure.h"
-import "C"
-
 import (
+	"errors"
 	"fmt"
-	"os"
 )
 
-func loadBlocks(stripeName string, k, m int) (blocks []LenReader, erasures []int) {
+// loadBlocks opens the data and parity blocks of a stripe. Blocks that
+// cannot be opened are erased. If the stripe has a manifest, blocks that
+// do not match their recorded size and checksums are erased as well, so
+// that silent corruption is repaired instead of decoded. Blocks that are
+// known to be lost are erased without opening them.
+func loadBlocks(o *options, names []string, manifest *Manifest, lost []bool) (blocks []LenReader, erasures []int) {
 	// Create an array to store handles to all data and parity blocks
-	blocks = make([]LenReader, k + m)
-	
+	blocks = make([]LenReader, len(names))
+
 	// The extra int is required for the -1 stopper
-	erasures = make([]int, k + m + 1)
-	
+	erasures = make([]int, len(names)+1)
+
 	x := 0
-	var blockName string
-	
-	for i:= 0 ; i < k ; i++ {
-		blockName = fmt.Sprintf("%s_k%d", stripeName, i)
-		
-		block, err := os.Open(blockName)
-		if err != nil {
+
+	for i, blockName := range names {
+
+		if lost[i] {
 			erasures[x] = i
-			blocks[i] = nil
 			x++
-			fmt.Printf("Failed to open file: %s\n", blockName)
-		} else {
-		
-			// I'm assuming that if no file a found, the file pointer
-			// returned will just dereference to a zero size file
-			blocks[i] = newFileLenReader(block)
-			fmt.Printf("Successfully opened file: %s\n", blockName)
-		}
-	}
-	
-	for i:= 0 ; i < m ; i++ {
-		blockName = fmt.Sprintf("%s_m%d", stripeName, i)
-		
-		block, err := os.Open(blockName)
+			o.logf("Lost root of file: %s", blockName)
+			continue
+		}
+
+		block, err := openShard(o.store, blockName)
 		if err != nil {
-			// The coding ids are above the data ids
-			erasures[x] = k + i
-			blocks[k+i] = nil
+			erasures[x] = i
 			x++
-			fmt.Printf("Failed to open file: %s\n", blockName)
-		} else {
-		
-			// I'm assuming that if no file a found, the file pointer
-			// returned will just dereference to a zero size file
-			blocks[k + i] = newFileLenReader(block)
-			fmt.Printf("Successfully opened file: %s\n", blockName)
+			o.logf("Failed to open file: %s", blockName)
+			continue
 		}
+
+		if manifest != nil {
+			if err = manifest.verifyBlock(block, i); err != nil {
+				block.Close()
+				erasures[x] = i
+				x++
+				o.logf("Corrupt file: %s: %v", blockName, err)
+				continue
+			}
+		}
+
+		blocks[i] = block
 	}
 	// A stopper used by the jerasure library
 	erasures[x] = -1
-	
+
 	return blocks, erasures
 }
 
-func Decode(stripeName string, code Coder) (err error) {
-	
+// stripeLayout locates the blocks of a stripe.
+type stripeLayout struct {
+	// names holds the names of the blocks in the store, and unplaced
+	// their names without their roots.
+	names, unplaced []string
+	// placement holds the roots of the blocks, if they are placed.
+	placement []string
+	// lost marks the blocks on roots that lost the manifest.
+	lost []bool
+}
+
+// newStripeLayout locates the blocks of a stripe, with the naming and
+// roots selected in o or recorded in the manifest. The roots that hold
+// a copy of the manifest are available.
+func newStripeLayout(o *options, stripeName string, k, m int, manifest *Manifest, available map[string]bool) (*stripeLayout, error) {
+	template := o.naming
+	if template == "" && manifest != nil {
+		template = manifest.Naming
+	}
+	naming, err := parseNaming(template)
+	if err != nil {
+		return nil, err
+	}
+	this := &stripeLayout{lost: make([]bool, k+m)}
+	this.unplaced = naming.blocks(stripeName, k, m)
+	this.names = this.unplaced
+	if o.roots == nil {
+		return this, nil
+	}
+
+	// The blocks of a placed stripe on roots without a copy of the
+	// manifest are lost
+	if manifest != nil && manifest.Placement != nil {
+		this.placement = append([]string(nil), manifest.Placement...)
+		for id, root := range this.placement {
+			this.lost[id] = !available[root]
+		}
+	} else if this.placement, err = Placement(stripeName, k, m, o.roots); err != nil {
+		return nil, err
+	}
+	this.names = placeBlocks(this.unplaced, this.placement)
+	return this, nil
+}
+
+// Decode regenerates the erased data and coding blocks of a stripe
+// with the specified coder and writes them to disc. The blocks are read
+// from and written to the ShardStore selected with WithStore, under the
+// names given by the naming template selected with WithNaming, or else
+// the one recorded in the manifest. The blocks of stripes placed on
+// several roots are found on the roots selected with WithRoots.
+//
+// If the stripe has a manifest, the code has to match it, the padded
+// data blocks are truncated to the size recorded by Encode and the
+// regenerated blocks are checked against their recorded checksums. A
+// code without a buffer size takes the one recorded in the manifest.
+//
+// The regenerated blocks are written as they are decoded, so memory use
+// is bounded by the number of buffers in flight, set with WithInflight.
+// Buffers are decoded one at a time, unless more workers are requested
+// with WithWorkers.
+func Decode(stripeName string, code Coder, opts ...Option) (err error) {
+	o := newOptions(opts)
+
 	k := code.K()
 	m := code.M()
-	buffersize := code.Buffersize()
-	
-	blocks, erasures := loadBlocks(stripeName, k, m)
-	
-	numErasures := numErasures(erasures)
+
+	manifest, available, err := readStripeManifest(o.store, stripeName, o.roots)
+	if errors.Is(err, ErrNoManifest) {
+		manifest = nil
+	} else if err != nil {
+		return err
+	} else if err = manifest.check(code); err != nil {
+		return err
+	} else if code.Buffersize() == 0 {
+		// The buffer size of the stripe is not derived from its size,
+		// so it has to come from the manifest
+		if err = code.CheckFileSize(manifest.BufferSize); err != nil {
+			return err
+		}
+	}
+
+	layout, err := newStripeLayout(o, stripeName, k, m, manifest, available)
+	if err != nil {
+		return err
+	}
+	names, placement, lost := layout.names, layout.placement, layout.lost
+
+	blocks, erasures := loadBlocks(o, names, manifest, lost)
+	defer closeBlocks(blocks)
+
+	numErasures, err := numErasures(erasures)
+	if err != nil {
+		return err
+	}
 	if numErasures > m {
-		msg := fmt.Sprintf("Found more erasures than parities. Found %d erasures with only %d parities.", numErasures, m)
-		panic(msg)	//This panic might be downgraded to a return error agter testing.
+		return fmt.Errorf("%w: found %d erasures with only %d parities", ErrTooManyErasures, numErasures, m)
 	} else if numErasures == 0 {
-		fmt.Println("No erasures found. Returning.")
 		return nil
 	}
-	
-	bw := newFileBlockWriter(stripeName)
-	
+
 	// Read the block sizes and ensure that all blocks are the same size
-	size, err := compareAndGetSizes(blocks);
-	if err != nil {
+	var size int64
+	if manifest != nil {
+		size = manifest.Size
+	} else if size, err = compareAndGetSizes(blocks); err != nil {
 		return err
 	}
 
-	fmt.Println("Erasure: ", erasures)
-	
-	// Ensure that block size is a multiple of buffer size and that
-	// both block size and buffer size are multiples of the parameter product
-	code.CheckFileSize(size)
-	
+	// Ensure that the buffer size is a multiple of the parameter product
+	if err = code.CheckFileSize(size); err != nil {
+		return err
+	}
+	buffersize := code.Buffersize()
+	if err = checkBlockSizes(blocks, k, size, roundUp(size, buffersize)); err != nil {
+		return err
+	}
 
-	// After our parameter check, size is a multiple of buffer size
 	// Compute the number of buffers that we'll have to read, before
-	// we've read a complete file.
-	readins := int(size / buffersize)
-
-	// Create data and coding buffers, where each buffer stores all
-	// the data or coding blocks respectivly
-	data := allocateBuffers(k, buffersize)
-	coding := allocateBuffers(m, buffersize)
-	total := int64(0)
-	
- 	code.PrintInfo()
-
-	for i:= 0; i < readins; i++ {
-		fmt.Printf("readins=%d,i=%d,size=%d,total=%d,buffersize=%d\n", readins, i, size, total, buffersize)
-		n := 0
-		
+	// we've read a complete file. The last buffer may be padded.
+	readins := int(roundUp(size, buffersize) / buffersize)
+
+	var hashers []*blockHasher
+	if manifest != nil {
+		if hashers, err = newBlockHashers(manifest.Hash, k+m); err != nil {
+			return err
+		}
+	}
+
+	// Move the blocks of lost roots to spare roots
+	ids, rewrite := erasures[:numErasures], false
+	if manifest != nil && placement != nil {
+		rewrite = relocate(placement, names, layout.unplaced, ids, lost, o.roots)
+	}
+
+	bw, err := newShardBlockWriter(o.store, names, k, ids, o.sync)
+	if err != nil {
+		return err
+	}
+	defer bw.Close()
+
+	// Buffers are read and written in order, but decoded by up to
+	// o.workers goroutines at once. At most o.inflight rounds of k+m
+	// buffers are held in memory.
+	p := &pipeline{
+		workers:  o.workers,
+		inflight: o.inflight,
+		k:        k,
+		m:        m,
+		size:     buffersize,
+	}
+
+	p.read = func(r *round) error {
+		want := min(buffersize, size-int64(r.index)*buffersize)
+
 		// Read the data blocks into memory
-		for j := 0 ; j < k; j++ {
-			
+		for j := 0; j < k; j++ {
+
 			if wasErased(erasures, j) {
 				continue
 			}
-			
-			n, err = blocks[j].Read(data[j])
+
+			n, _, err := readBuffer(blocks[j], r.data[j])
 			if err != nil {
 				return err
 			}
-			if n < len(data[j]) {
-				panic("Less data than the buffer size was read.")
+			if int64(n) < want {
+				return ErrShortRead
 			}
-			
-			total += int64(n)
 		}
-		
+
 		// Read the coding blocks into memory
-		for j := 0 ; j < m; j++ {
-			
-			if wasErased(erasures, k + j) {
+		for j := 0; j < m; j++ {
+
+			if wasErased(erasures, k+j) {
 				continue
 			}
-			
-			n, err = blocks[k + j].Read(coding[j])
+
+			n, _, err := readBuffer(blocks[k+j], r.coding[j])
 			if err != nil {
 				return err
 			}
-			if n < len(coding[j]) {
-				panic("Less data than the buffer size was read.")
+			if n < len(r.coding[j]) {
+				return ErrShortRead
 			}
-			
-			total += int64(n)
 		}
-			
-		code.Decode(data, coding, erasures)
+		return nil
+	}
+
+	p.code = func(r *round) error {
+		return code.Decode(r.data, r.coding, erasures)
+	}
 
-		bw.Data(data)
-		bw.Coding(coding)
+	p.write = func(r *round) error {
+		want := min(buffersize, size-int64(r.index)*buffersize)
+
+		// Strip the padding from the data blocks
+		unpadded := make([][]byte, k)
+		for j := range r.data {
+			unpadded[j] = r.data[j][:want]
+		}
+
+		// Checksum the regenerated blocks
+		for _, id := range erasures[:numErasures] {
+			if hashers == nil {
+				break
+			}
+			if id < k {
+				hashers[id].add(unpadded[id])
+			} else {
+				hashers[id].add(r.coding[id-k])
+			}
+		}
+
+		if err := bw.Data(unpadded); err != nil {
+			return err
+		}
+		return bw.Coding(r.coding)
+	}
+
+	if err = p.run(readins); err != nil {
+		return err
+	}
+
+	// Refuse to keep blocks that were regenerated from corrupt data
+	if manifest != nil {
+		for _, id := range erasures[:numErasures] {
+			if hashers[id].checksums().Checksum != manifest.Blocks[id].Checksum {
+				return fmt.Errorf("%w: regenerated block %d", ErrChecksumMismatch, id)
+			}
+		}
+	}
+	if err = bw.Commit(); err != nil {
+		return err
+	}
+	if rewrite {
+		manifest.Placement = placement
+		return writeManifest(o.store, stripeName, manifest, o.sync)
+	}
+	return nil
+}
+
+// checkBlockSizes ensures that the data blocks that were found are size
+// bytes long and the coding blocks padded bytes long.
+func checkBlockSizes(blocks []LenReader, k int, size, padded int64) error {
+	for i, block := range blocks {
+		if block == nil {
+			continue
+		}
+		want := size
+		if i >= k {
+			want = padded
+		}
+		if block.Len() != want {
+			return fmt.Errorf("%w: block %d is %d bytes, expected %d", ErrBlocksUnequal, i, block.Len(), want)
+		}
 	}
-	bw.WriteErased(erasures)
-	return
+	return nil
 }
 
-func wasErased (erasures []int, id int) bool {
-	for _, value := range(erasures) {
+func wasErased(erasures []int, id int) bool {
+	for _, value := range erasures {
 		if value == id {
 			return true
 		} else if value == -1 {
@@ -181,12 +338,12 @@ func wasErased (erasures []int, id int) bool {
 	return false
 }
 
-func numErasures (erasures []int) int {
-	for num, value := range(erasures) {
-		if (value == -1) {
-			return num
+// numErasures counts the erasures in a -1 terminated erasure list.
+func numErasures(erasures []int) (int, error) {
+	for num, value := range erasures {
+		if value == -1 {
+			return num, nil
 		}
 	}
-	panic("No -1 found in erasure slice")
-	return -1
+	return 0, fmt.Errorf("%w: no -1 found in erasure slice", ErrInvalidParams)
 }
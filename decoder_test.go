@@ -15,6 +15,13 @@
 package goerasure
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -27,11 +34,14 @@ func TestDecoder(t *testing.T) {
 	buffersize := int64(258048)
 
 	// Select a Liberation code from the codes.go library
-	code := NewLiberationCode(k, m, w, packetsize, buffersize)
+	code, err := NewLiberationCode(k, m, w, packetsize, buffersize)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	err := Decode("testfiles/decoderTest", code)
+	err = Decode(copyTestStripe(t, "decoderTest"), code)
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
 	}
 }
 
@@ -42,13 +52,181 @@ func BenchmarkDecoder(b *testing.B) {
 	w := 7
 	packetsize := 128
 	buffersize := int64(258048)
-	
+
 	// Select a Liberation code from the codes.go library
-	code := NewLiberationCode(k, m, w, packetsize, buffersize)
-	
-	stripeName := "testfiles/decoderTest"
-	
+	code, err := NewLiberationCode(k, m, w, packetsize, buffersize)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	stripeName := copyTestStripe(b, "decoderTest")
+
 	for i := 0; i < b.N; i++ {
-		Decode(stripeName, code)
+		if err = Decode(stripeName, code); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// copyTestStripe copies the blocks of a stripe in testfiles to a
+// temporary directory and returns the name of the copy, so that tests
+// leave testfiles untouched.
+func copyTestStripe(t testing.TB, name string) string {
+	dir := t.TempDir()
+	blocks, err := filepath.Glob(filepath.Join("testfiles", name+"_*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range blocks {
+		buf, err := os.ReadFile(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(filepath.Join(dir, filepath.Base(block)), buf, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, name)
+}
+
+// newTestStripe writes k random data blocks of the given size to a
+// temporary directory and returns the stripe name and the blocks. The
+// directory is removed when the test ends.
+func newTestStripe(t testing.TB, k, size int) (string, [][]byte) {
+	dir, err := os.MkdirTemp("testfiles", "stripe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	stripeName := filepath.Join(dir, "stripe")
+
+	blocks := make([][]byte, k)
+	for i := range blocks {
+		blocks[i] = make([]byte, size)
+		rand.Read(blocks[i])
+		if err = os.WriteFile(fmt.Sprintf("%s_k%d", stripeName, i), blocks[i], 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return stripeName, blocks
+}
+
+func TestDecodePadded(t *testing.T) {
+	k, m := 6, 2
+	buffersize := int64(258048)
+	code, err := NewLiberationCode(k, m, 7, 128, buffersize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Data blocks that are not a multiple of the buffer size
+	stripeName, blocks := newTestStripe(t, k, int(buffersize)+1000)
+
+	if err = Encode(stripeName, code); err != nil {
+		t.Fatal(err)
+	}
+	coding, err := os.ReadFile(stripeName + "_m0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(coding)) != 2*buffersize {
+		t.Fatalf("expected padded coding block of %d bytes, got %d", 2*buffersize, len(coding))
+	}
+
+	os.Remove(stripeName + "_k1")
+	os.Remove(stripeName + "_m0")
+
+	if err = Decode(stripeName, code); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := os.ReadFile(stripeName + "_k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, blocks[1]) {
+		t.Errorf("decoded data block differs from the original, got %d bytes, expected %d", len(decoded), len(blocks[1]))
+	}
+	decoded, err = os.ReadFile(stripeName + "_m0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, coding) {
+		t.Errorf("decoded coding block differs from the original")
+	}
+}
+
+func TestDecoderWorkers(t *testing.T) {
+	k := 4
+	code, err := NewReedSolVanCode(k, 2, 8, 0, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stripeName, blocks := newTestStripe(t, k, 20*4096+7)
+	if err = Encode(stripeName, code); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range [][]Option{{WithWorkers(3)}, {WithWorkers(8), WithInflight(1)}, {WithInflight(1)}} {
+		os.Remove(stripeName + "_k2")
+		os.Remove(stripeName + "_m0")
+
+		if err = Decode(stripeName, code, opts...); err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := os.ReadFile(stripeName + "_k2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded, blocks[2]) {
+			t.Error("decoded data block differs from the original")
+		}
+	}
+}
+
+// TestDecoderLogger decodes a stripe with a missing block, which is
+// reported to the logger and not to stdout.
+func TestDecoderLogger(t *testing.T) {
+	k, m := 4, 2
+	code, err := NewCauchyGoodCode(k, m, 8, 8, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer code.Close()
+
+	store := NewMemStore()
+	for i := 0; i < k; i++ {
+		buf := make([]byte, 4096)
+		rand.Read(buf)
+		writeTestShard(t, store, fmt.Sprintf("stripe_k%d", i), buf)
+	}
+
+	// Capture anything written to stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	var logged bytes.Buffer
+	if err = Encode("stripe", code, WithStore(store)); err != nil {
+		t.Fatal(err)
+	}
+	store.Delete("stripe_k1")
+	if err = Decode("stripe", code, WithStore(store), WithLogger(log.New(&logged, "", 0))); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Stdout = stdout
+	w.Close()
+	printed, _ := io.ReadAll(r)
+	if len(printed) != 0 {
+		t.Errorf("expected nothing on stdout, got %q", printed)
+	}
+	if want := "Failed to open file: stripe_k1\n"; logged.String() != want {
+		t.Errorf("expected %q to be logged, got %q", want, logged.String())
 	}
 }
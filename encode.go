@@ -1,5 +1,3 @@
-// +build linux
-
 //   Copyright 2013 Vastech SA (PTY) LTD
 //
 //   Licensed under the Apache License, Version 2.0 (the "License");
@@ -16,31 +14,18 @@
 
 package goerasure
 
-// #include "jerasure.h"
-import "C"
-
-import (
-	"fmt"
-	"os"
-)
-
-func loadDataBlocks(stripeName string, k int) (blocks []LenReader) {
+// loadDataBlocks opens the data blocks with the given names.
+func loadDataBlocks(store ShardStore, names []string) (blocks []LenReader, err error) {
 	// Create an array to store handles to all data blocks
-	blocks = make([]LenReader, k)
-	
-	for i:= 0 ; i < k ; i++ {
-		blockName := fmt.Sprintf("%s_k%d", stripeName, i)
-		
-		//fmt.Printf("Encoder opening file: %s\n", blockName)
-		block, err := os.Open(blockName)
+	blocks = make([]LenReader, len(names))
+
+	for i, name := range names {
+		block, err := openShard(store, name)
 		if err != nil {
-			panic(err)
+			closeBlocks(blocks)
+			return nil, err
 		}
-		
-		// This is where I was. The issue is whether this function
-		// returns something new, which has to be allocs, or whether
-		// it just works.
-		blocks[i] = newFileLenReader(block)
+		blocks[i] = block
 	}
 	return
 }
@@ -48,59 +33,131 @@ func loadDataBlocks(stripeName string, k int) (blocks []LenReader) {
 // The Encode function takes in a stripe name, where the stripe name
 // is the base name of a stripe of data blocks. It then encodes the
 // data blocks with the specified coder and writes the coding blocks to disc.
-func Encode(stripeName string, code Coder) (err error) {
+//
+// The blocks are read from and written to files relative to the working
+// directory, unless another ShardStore is selected with WithStore. They
+// are named <stripe>_k<i> and <stripe>_m<i>, unless another naming
+// template is selected with WithNaming. Blocks are placed on distinct
+// roots with WithRoots.
+//
+// Data blocks that are not a multiple of the buffer size are padded
+// with zeros while encoding. The coding blocks are written with the
+// padding. A manifest recording the code, the unpadded block size and
+// the checksum of every block is written alongside the blocks, so that
+// DecodeStripe can restore the data blocks exactly.
+//
+// The block checksums use CRC32C, unless another hash is selected with
+// WithHash. Buffers are encoded one at a time, unless more workers are
+// requested with WithWorkers.
+func Encode(stripeName string, code Coder, opts ...Option) (err error) {
+	o := newOptions(opts)
 
 	k := code.K()
 	m := code.M()
-	bufferSize := code.Buffersize()
-	
-	blocks := loadDataBlocks(stripeName, k)
-	
-	bw := newFileBlockWriter(stripeName)
-		
+
+	naming, err := parseNaming(o.naming)
+	if err != nil {
+		return err
+	}
+	names := naming.blocks(stripeName, k, m)
+	var placement []string
+	if o.roots != nil {
+		if placement, err = Placement(stripeName, k, m, o.roots); err != nil {
+			return err
+		}
+		names = placeBlocks(names, placement)
+	}
+
+	blocks, err := loadDataBlocks(o.store, names[:k])
+	if err != nil {
+		return err
+	}
+	defer closeBlocks(blocks)
+
 	// Read the block sizes and ensure that all blocks are the same size
 	size, err := compareAndGetSizes(blocks)
 	if err != nil {
 		return err
 	}
 
-	// Ensure that block size is a multiple of buffer size and that
-	// both block size and buffer size are multiples of the parameter product
-	code.CheckFileSize(size)
+	// Ensure that the buffer size is a multiple of the parameter product
+	if err = code.CheckFileSize(size); err != nil {
+		return err
+	}
+	bufferSize := code.Buffersize()
 
-	// After our parameter check, size is a multiple of buffer size
 	// Compute the number of buffers that we'll have to read, before
-	// we've read a complete file.
-	readins := int(size / bufferSize)
+	// we've read a complete file. The last buffer is padded.
+	readins := int(roundUp(size, bufferSize) / bufferSize)
 
-	// Create data and coding buffers, where wach buffer stores all
-	// the data or coding blocks respectivly
-	data := allocateBuffers(k, bufferSize)
-	coding := allocateBuffers(m, bufferSize)
-	total := int64(0)
+	manifest := newManifest(code, size, o.hash)
+	if o.naming != "" && o.naming != DefaultNaming {
+		manifest.Naming = o.naming
+	}
+	manifest.Placement = placement
+	hashers, err := newBlockHashers(o.hash, k+m)
+	if err != nil {
+		return err
+	}
 
-	for i := 0; i < readins; i++ {
-		n := 0
+	ids := make([]int, m)
+	for j := range ids {
+		ids[j] = k + j
+	}
+	bw, err := newShardBlockWriter(o.store, names, k, ids, o.sync)
+	if err != nil {
+		return err
+	}
+	defer bw.Close()
+
+	// Buffers are read and written in order, but encoded by up to
+	// o.workers goroutines at once.
+	p := &pipeline{
+		workers:  o.workers,
+		inflight: o.inflight,
+		k:        k,
+		m:        m,
+		size:     bufferSize,
+	}
+
+	p.read = func(r *round) error {
+		want := min(bufferSize, size-int64(r.index)*bufferSize)
 
 		for j := 0; j < k; j++ {
 			// Read the file contents of file j directly into the data
-			n, err = blocks[j].Read(data[j])
+			n, _, err := readBuffer(blocks[j], r.data[j])
 			if err != nil {
 				return err
 			}
-			if n < int(bufferSize) {
-				panic("Less data than the buffer size was read.")
+			if int64(n) < want {
+				return ErrShortRead
 			}
-
-			total += int64(n)
+			hashers[j].add(r.data[j][:want])
 		}
+		return nil
+	}
+
+	p.code = func(r *round) error {
+		return code.Encode(r.data, r.coding)
+	}
 
-		code.Encode(data, coding)
+	p.write = func(r *round) error {
+		for j := range r.coding {
+			hashers[k+j].add(r.coding[j])
+		}
+		return bw.Coding(r.coding)
+	}
 
-		bw.Data(data)
-		bw.Coding(coding)
+	if err = p.run(readins); err != nil {
+		return err
 	}
+
 	// Write out coding blocks
-	bw.WriteCoding()
-	return
+	if err = bw.Commit(); err != nil {
+		return err
+	}
+	for i := range hashers {
+		manifest.Blocks[i] = hashers[i].checksums()
+	}
+	return writeManifest(o.store, stripeName, manifest, o.sync)
 }
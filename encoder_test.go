@@ -15,6 +15,8 @@
 package goerasure
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -27,11 +29,14 @@ func TestEncoder(t *testing.T) {
 	buffersize := int64(258048)
 
 	// Select a Liberation code from the codes.go library
-	code := NewLiberationCode(k, m, w, packetsize, buffersize)
+	code, err := NewLiberationCode(k, m, w, packetsize, buffersize)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	err := Encode("testfiles/encoderTest", code)
+	err = Encode(copyTestStripe(t, "encoderTest"), code)
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
 	}
 }
 
@@ -42,17 +47,70 @@ func BenchmarkEncoder(b *testing.B) {
 	w := 7
 	packetsize := 128
 	buffersize := int64(258048)
-	
+
 	// Select a Liberation code from the codes.go library
-	code := NewLiberationCode(k, m, w, packetsize, buffersize)
-	
-	stripeName := "testfiles/encoderTest"
-	
+	code, err := NewLiberationCode(k, m, w, packetsize, buffersize)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	stripeName := copyTestStripe(b, "encoderTest")
+
 	for i := 0; i < b.N; i++ {
 		err := Encode(stripeName, code)
 		if err != nil {
-			panic(err)
+			b.Fatal(err)
 		}
 		b.SetBytes(67108864)
 	}
 }
+
+func TestEncoderWorkers(t *testing.T) {
+	k := 4
+	code, err := NewReedSolVanCode(k, 2, 8, 0, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stripeName, _ := newTestStripe(t, k, 20*4096+7)
+
+	var expected *Manifest
+	for _, workers := range []int{1, 3, 8} {
+		if err = Encode(stripeName, code, WithWorkers(workers)); err != nil {
+			t.Fatal(err)
+		}
+		manifest, err := ReadManifest(stripeName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected == nil {
+			expected = manifest
+		} else if !reflect.DeepEqual(manifest, expected) {
+			t.Errorf("%d workers produced different coding blocks than 1 worker", workers)
+		}
+	}
+}
+
+func BenchmarkEncoderWorkers(b *testing.B) {
+	k := 6
+	buffersize := int64(258048)
+	readins := 16
+
+	code, err := NewLiberationCode(k, 2, 7, 128, buffersize)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	stripeName, _ := newTestStripe(b, k, readins*int(buffersize))
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(int64(k*readins) * buffersize)
+			for i := 0; i < b.N; i++ {
+				if err := Encode(stripeName, code, WithWorkers(workers)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
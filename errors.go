@@ -0,0 +1,57 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import "errors"
+
+// The errors below are returned (possibly wrapped with more detail) by
+// the coders and by Encode and Decode. Use errors.Is to test for them.
+var (
+	// ErrInvalidParams is returned when the coding parameters do not
+	// satisfy the requirements of a code.
+	ErrInvalidParams = errors.New("invalid coding parameters")
+	// ErrMisalignedBuffer is returned when a buffer or file size is not
+	// aligned to the coding parameters.
+	ErrMisalignedBuffer = errors.New("buffer size is not aligned to the coding parameters")
+	// ErrTooManyErasures is returned when more blocks were erased than
+	// the code has parity blocks.
+	ErrTooManyErasures = errors.New("too many erasures")
+	// ErrDecodeFailed is returned when jerasure could not decode a
+	// stripe.
+	ErrDecodeFailed = errors.New("erasure decoding failed")
+	// ErrShortRead is returned when less data than the buffer size could
+	// be read from a block.
+	ErrShortRead = errors.New("less data than the buffer size was read")
+	// ErrNoData is returned when no data blocks were given.
+	ErrNoData = errors.New("source data is empty")
+	// ErrBlocksUnequal is returned when the blocks of a stripe differ in
+	// size.
+	ErrBlocksUnequal = errors.New("input block sizes do not match")
+	// ErrNoManifest is returned when a stripe has no manifest.
+	ErrNoManifest = errors.New("stripe has no manifest")
+	// ErrChecksumMismatch is returned when a block does not match the
+	// checksum recorded in the manifest.
+	ErrChecksumMismatch = errors.New("block checksum mismatch")
+	// ErrUnknownHash is returned for checksum hashes that have not been
+	// registered with RegisterHash.
+	ErrUnknownHash = errors.New("unknown checksum hash")
+	// ErrUnknownTechnique is returned by NewCode for unknown codes.
+	ErrUnknownTechnique = errors.New("unknown coding technique")
+	// ErrNotInvertible is returned when a matrix that has to be inverted
+	// is singular or not square.
+	ErrNotInvertible = errors.New("matrix is not invertible")
+	// ErrClosed is returned when a code is used after it was closed.
+	ErrClosed = errors.New("code is closed")
+)
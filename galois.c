@@ -1,3 +1,6 @@
+//go:build linux && !purego
+// +build linux,!purego
+
 /* Galois.c
  * James S. Plank
  * April, 2007
@@ -0,0 +1,299 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+// Package gf implements arithmetic in the Galois fields GF(2^w), for w
+// from 1 to 32. It uses the same primitive polynomials as galois.c, so
+// codes built on it produce the same bytes as the jerasure library.
+//
+// Elements of GF(2^w) are represented by the integers below 2^w. Adding
+// two elements XORs them. Regions of memory are treated as arrays of
+// w-bit words in the byte order of the host, as jerasure does, which
+// requires w to be 8, 16 or 32.
+package gf
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"sync"
+)
+
+// polys holds the primitive polynomial of each field, including the
+// high order bit that galois.c omits for w = 32.
+var polys = [33]uint64{
+	0,
+	03, 07, 013, 023, 045, 0103, 0211, 0435,
+	01021, 02011, 04005, 010123, 020033, 042103, 0100003, 0210013,
+	0400011, 01000201, 02000047, 04000011, 010000005, 020000003, 040000041, 0100000207,
+	0200000011, 0400000107, 01000000047, 02000000011, 04000000005, 010040000007, 020000000011, 040020000007,
+}
+
+// maxTableW is the largest word size for which log tables are built.
+// Larger fields multiply by shifting.
+const maxTableW = 16
+
+// GF is the Galois field GF(2^w). It is safe for concurrent use.
+type GF struct {
+	w    int
+	poly uint64
+	// log and exp map elements to their logarithms base 2 and back. exp
+	// is twice the size of the multiplicative group, so that the sum of
+	// two logarithms can be looked up directly.
+	log []uint32
+	exp []uint32
+
+	// babySteps maps 2^j to j for the discrete logarithms of fields
+	// without log tables. It is built on first use.
+	babyOnce  sync.Once
+	babySteps map[uint32]uint32
+}
+
+var (
+	fields [33]*GF
+	once   [33]sync.Once
+)
+
+// Field returns the field GF(2^w), for w from 1 to 32. Fields are built
+// once and shared.
+func Field(w int) (*GF, error) {
+	if w < 1 || w > 32 {
+		return nil, fmt.Errorf("gf: word size %d is not between 1 and 32", w)
+	}
+	once[w].Do(func() {
+		f := &GF{w: w, poly: polys[w]}
+		if w <= maxTableW {
+			f.createTables()
+		}
+		fields[w] = f
+	})
+	return fields[w], nil
+}
+
+// createTables builds the log and exp tables by stepping through the
+// powers of 2.
+func (f *GF) createTables() {
+	n := uint32(1) << uint(f.w)
+	f.log = make([]uint32, n)
+	f.exp = make([]uint32, 2*(n-1))
+
+	b := uint64(1)
+	for j := uint32(0); j < n-1; j++ {
+		f.log[b] = j
+		f.exp[j] = uint32(b)
+		f.exp[j+n-1] = uint32(b)
+		b <<= 1
+		if b&(1<<uint(f.w)) != 0 {
+			b ^= f.poly
+		}
+	}
+}
+
+// W returns the word size of the field.
+func (f *GF) W() int {
+	return f.w
+}
+
+// Mul returns the product of a and b.
+func (f *GF) Mul(a, b uint32) uint32 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	if f.log != nil {
+		return f.exp[f.log[a]+f.log[b]]
+	}
+	return f.shiftMul(a, b)
+}
+
+// shiftMul multiplies a and b as polynomials and reduces the product by
+// the primitive polynomial.
+func (f *GF) shiftMul(a, b uint32) uint32 {
+	var prod uint64
+	x := uint64(b)
+	for i := 0; i < f.w; i++ {
+		if a&(1<<uint(i)) != 0 {
+			prod ^= x
+		}
+		x <<= 1
+		if x&(1<<uint(f.w)) != 0 {
+			x ^= f.poly
+		}
+	}
+	return uint32(prod)
+}
+
+// Div returns a divided by b. It panics if b is zero.
+func (f *GF) Div(a, b uint32) uint32 {
+	if b == 0 {
+		panic("gf: division by zero")
+	}
+	if a == 0 {
+		return 0
+	}
+	if f.log != nil {
+		return f.exp[f.log[a]+uint32(len(f.exp)/2)-f.log[b]]
+	}
+	return f.shiftMul(a, f.Inv(b))
+}
+
+// Inv returns the multiplicative inverse of a. It panics if a is zero.
+func (f *GF) Inv(a uint32) uint32 {
+	if a == 0 {
+		panic("gf: inverse of zero")
+	}
+	if f.log != nil {
+		return f.Div(1, a)
+	}
+
+	// a^(2^w-1) = 1, so a^(2^w-2) is the inverse of a
+	inv := uint32(1)
+	for i := 1; i < f.w; i++ {
+		a = f.shiftMul(a, a)
+		inv = f.shiftMul(inv, a)
+	}
+	return inv
+}
+
+// order returns the number of non-zero elements in the field.
+func (f *GF) order() uint64 {
+	return 1<<uint(f.w) - 1
+}
+
+// Exp returns 2 raised to the power n. 2 generates the multiplicative
+// group, so Exp and Log are inverses. Exponents and logarithms are
+// int64, which holds them for every w even where int has 32 bits.
+func (f *GF) Exp(n int64) uint32 {
+	order := int64(f.order())
+	e := n % order
+	if e < 0 {
+		e += order
+	}
+	if f.exp != nil {
+		return f.exp[e]
+	}
+
+	x, b := uint32(1), uint32(2)
+	for ; e > 0; e >>= 1 {
+		if e&1 != 0 {
+			x = f.shiftMul(x, b)
+		}
+		b = f.shiftMul(b, b)
+	}
+	return x
+}
+
+// Log returns the logarithm base 2 of a, between 0 and 2^w-2. It panics
+// if a is zero.
+//
+// Fields with w above 16 have no log tables, so the logarithm is found
+// with a baby-step giant-step search, which takes about 2^(w/2)
+// multiplications.
+func (f *GF) Log(a uint32) int64 {
+	if a == 0 {
+		panic("gf: logarithm of zero")
+	}
+	if f.log != nil {
+		return int64(f.log[a])
+	}
+
+	// Write the logarithm as i*n + j with j < n. The baby steps map 2^j
+	// to j, and the giant steps multiply a by 2^-n until one is hit.
+	n := uint64(1) << uint((f.w+1)/2)
+	f.babyOnce.Do(func() {
+		f.babySteps = make(map[uint32]uint32, n)
+		x := uint32(1)
+		for j := uint64(0); j < n; j++ {
+			f.babySteps[x] = uint32(j)
+			x = f.shiftMul(x, 2)
+		}
+	})
+
+	step := f.Inv(f.Exp(int64(n)))
+	x := a
+	for i := uint64(0); i <= f.order()/n; i++ {
+		if j, ok := f.babySteps[x]; ok {
+			return int64((i*n + uint64(j)) % f.order())
+		}
+		x = f.shiftMul(x, step)
+	}
+	panic("gf: no logarithm found")
+}
+
+// MulRegion multiplies the words of src by c and stores them in dst, or
+// adds them to dst if add is set, like galois_w08_region_multiply and its
+// w = 16 and 32 counterparts. dst and src may be the same region. It
+// panics unless w is 8, 16 or 32 and the regions are of equal size and
+// a multiple of the word size.
+func (f *GF) MulRegion(dst, src []byte, c uint32, add bool) {
+	if f.w != 8 && f.w != 16 && f.w != 32 {
+		panic(fmt.Sprintf("gf: region multiply with word size %d", f.w))
+	}
+	if len(dst) != len(src) || len(src)%(f.w/8) != 0 {
+		panic("gf: regions of unequal or unaligned size")
+	}
+
+	// Split the multiplication by c into one table for every byte of
+	// the word, so that a word is multiplied with a lookup per byte.
+	tables := make([][256]uint32, f.w/8)
+	for i := range tables {
+		for b := range tables[i] {
+			tables[i][b] = f.Mul(c, uint32(b)<<(8*uint(i)))
+		}
+	}
+
+	switch f.w {
+	case 8:
+		t := &tables[0]
+		if add {
+			for i, b := range src {
+				dst[i] ^= byte(t[b])
+			}
+		} else {
+			for i, b := range src {
+				dst[i] = byte(t[b])
+			}
+		}
+	case 16:
+		for i := 0; i < len(src); i += 2 {
+			x := binary.NativeEndian.Uint16(src[i:])
+			p := uint16(tables[0][byte(x)] ^ tables[1][x>>8])
+			if add {
+				p ^= binary.NativeEndian.Uint16(dst[i:])
+			}
+			binary.NativeEndian.PutUint16(dst[i:], p)
+		}
+	case 32:
+		for i := 0; i < len(src); i += 4 {
+			x := binary.NativeEndian.Uint32(src[i:])
+			p := tables[0][byte(x)] ^ tables[1][byte(x>>8)] ^ tables[2][byte(x>>16)] ^ tables[3][x>>24]
+			if add {
+				p ^= binary.NativeEndian.Uint32(dst[i:])
+			}
+			binary.NativeEndian.PutUint32(dst[i:], p)
+		}
+	}
+}
+
+// XorRegion adds src to dst. Addition is the same in every field, so it
+// is also available as the function XorRegion.
+func (f *GF) XorRegion(dst, src []byte) {
+	XorRegion(dst, src)
+}
+
+// XorRegion adds src to dst. It panics if src is shorter than dst.
+func XorRegion(dst, src []byte) {
+	if len(src) < len(dst) {
+		panic("gf: regions of unequal size")
+	}
+	subtle.XORBytes(dst, dst, src)
+}
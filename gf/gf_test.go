@@ -0,0 +1,116 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package gf
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"testing"
+)
+
+func TestField(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for w := 1; w <= 32; w++ {
+		f, err := Field(w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mask := uint32(1<<uint(w) - 1)
+
+		for i := 0; i < 1000; i++ {
+			a := rnd.Uint32()&mask | 1
+			b := rnd.Uint32() & mask
+
+			if f.Mul(a, f.Inv(a)) != 1 {
+				t.Fatalf("w=%d: %d * 1/%d != 1", w, a, a)
+			}
+			if f.Div(f.Mul(b, a), a) != b {
+				t.Fatalf("w=%d: %d * %d / %d != %d", w, b, a, a, b)
+			}
+			if f.log != nil && f.Mul(a, b) != f.shiftMul(a, b) {
+				t.Fatalf("w=%d: log table and shift products of %d and %d differ", w, a, b)
+			}
+		}
+	}
+
+	if _, err := Field(33); err == nil {
+		t.Error("expected w = 33 to be rejected")
+	}
+}
+
+func TestExpLog(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for _, w := range []int{4, 8, 16, 17, 31, 32} {
+		f, _ := Field(w)
+		mask := uint32(1<<uint(w) - 1)
+
+		if f.Exp(0) != 1 || f.Exp(1) != 2 || f.Exp(-1) != f.Inv(2) {
+			t.Errorf("w=%d: unexpected powers of 2", w)
+		}
+		for i := 0; i < 20; i++ {
+			a := rnd.Uint32()&mask | 1
+			b := rnd.Uint32()&mask | 1
+			if f.Exp(f.Log(a)) != a {
+				t.Fatalf("w=%d: 2^log(%d) != %d", w, a, a)
+			}
+			if f.Exp(f.Log(a)+f.Log(b)) != f.Mul(a, b) {
+				t.Fatalf("w=%d: 2^(log(%d)+log(%d)) != %d * %d", w, a, b, a, b)
+			}
+		}
+	}
+}
+
+func TestMulRegion(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for _, w := range []int{8, 16, 32} {
+		f, _ := Field(w)
+		c := rnd.Uint32() & uint32(1<<uint(w)-1)
+
+		src := make([]byte, 64)
+		rnd.Read(src)
+		dst := make([]byte, len(src))
+		rnd.Read(dst)
+		old := append([]byte(nil), dst...)
+
+		f.MulRegion(dst, src, c, false)
+		word := w / 8
+		for i := 0; i < len(src); i += word {
+			if got, want := load(dst[i:], word), f.Mul(load(src[i:], word), c); got != want {
+				t.Fatalf("w=%d: word %d is %d, expected %d", w, i/word, got, want)
+			}
+		}
+
+		copy(dst, old)
+		f.MulRegion(dst, src, c, true)
+		for i := 0; i < len(src); i += word {
+			if got, want := load(dst[i:], word), f.Mul(load(src[i:], word), c)^load(old[i:], word); got != want {
+				t.Fatalf("w=%d: added word %d is %d, expected %d", w, i/word, got, want)
+			}
+		}
+	}
+}
+
+func load(b []byte, word int) uint32 {
+	switch word {
+	case 1:
+		return uint32(b[0])
+	case 2:
+		return uint32(binary.NativeEndian.Uint16(b))
+	}
+	return binary.NativeEndian.Uint32(b)
+}
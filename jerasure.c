@@ -1,3 +1,6 @@
+//go:build linux && !purego
+// +build linux,!purego
+
 /* jerasure.c
  * James S. Plank
 
@@ -39,9 +42,11 @@ plank@cs.utk.edu
 
 #define talloc(type, num) (type *) malloc(sizeof(type)*(num))
 
-static double jerasure_total_xor_bytes = 0;
-static double jerasure_total_gf_bytes = 0;
-static double jerasure_total_memcpy_bytes = 0;
+/* goerasure: the counters are kept per thread, so that codes running
+   concurrently on other threads do not disturb jerasure_get_stats. */
+static __thread double jerasure_total_xor_bytes = 0;
+static __thread double jerasure_total_gf_bytes = 0;
+static __thread double jerasure_total_memcpy_bytes = 0;
 
 void jerasure_print_matrix(int *m, int rows, int cols, int w)
 {
@@ -1,5 +1,3 @@
-// +build linux
-
 //   Copyright 2013 Vastech SA (PTY) LTD
 //
 //   Licensed under the Apache License, Version 2.0 (the "License");
@@ -17,20 +15,32 @@
 // Package goerasure wraps the Jerasure C library in a Go object
 // oriented interface that allows the user to perform erasure coding
 // operations in Go using various erasure codes.
+//
+// On Linux with cgo the codes are computed by the Jerasure library.
+// Elsewhere, when cgo is disabled or when built with the purego tag, they
+// are computed by an implementation in Go that produces the same bytes.
 package goerasure
 
-// #include "jerasure.h"
-import "C"
-import "unsafe"
+import (
+	"fmt"
+)
 
-// Create and print a matrix in GF(2^w)
-func CreateAndPrint(r, c, w int) {
+// Create and print a matrix in GF(2^w). An error is returned if the
+// matrix has a negative size or w is not between 1 and 32.
+func CreateAndPrint(r, c, w int) error {
+	if r < 0 || c < 0 {
+		return fmt.Errorf("%w: a %dx%d matrix has a negative size", ErrInvalidParams, r, c)
+	}
+	if w < 1 || w > 32 {
+		return fmt.Errorf("%w: word size must be between 1 and 32", ErrInvalidParams)
+	}
 	matrix := make([]int, r*c)
-	n:=1
-	for i:=0; i<r*c; i++ {
+	n := 1
+	for i := 0; i < r*c; i++ {
 		matrix[i] = n
-		n = int(C.galois_single_multiply(C.int(n), 2, C.int(w)))
+		n = galoisMultiply(n, 2, w)
 	}
 
-	C.jerasure_print_matrix((*C.int)(unsafe.Pointer(&matrix[0])), C.int(r), C.int(c), C.int(w))
-}
\ No newline at end of file
+	PrintMatrix(matrix, r, c, w)
+	return nil
+}
@@ -15,9 +15,23 @@
 package goerasure
 
 import (
+	"errors"
 	"testing"
 )
 
 func TestFoo(t *testing.T) {
-	CreateAndPrint(5, 2, 3)
+	if err := CreateAndPrint(5, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateAndPrintInvalid(t *testing.T) {
+	for _, w := range []int{0, -1, 33} {
+		if err := CreateAndPrint(2, 2, w); !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("w=%d: expected ErrInvalidParams, got %v", w, err)
+		}
+	}
+	if err := CreateAndPrint(-1, 2, 8); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("expected ErrInvalidParams, got %v", err)
+	}
 }
\ No newline at end of file
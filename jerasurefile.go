@@ -0,0 +1,356 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// jerasureIntSize is the size of a C int, to multiples of which the
+// encoder program of jerasure pads files and rounds buffer sizes.
+const jerasureIntSize = 4
+
+// jerasureTechniques holds the values of the Coding_Technique enum of the
+// jerasure encoder and decoder programs, by technique name.
+var jerasureTechniques = map[string]int{
+	ReedSolVan: 0,
+	ReedSolR6:  1,
+	CauchyOrig: 2,
+	CauchyGood: 3,
+	Liberation: 4,
+	BlaumRoth:  5,
+	Liber8tion: 6,
+}
+
+// JerasureMeta describes a file encoded by the encoder program of
+// jerasure, as recorded in its Coding/<name>_meta.txt file.
+type JerasureMeta struct {
+	// Filename is the name of the file as it was given to the encoder.
+	Filename string
+	// Size is the size of the file before it was padded.
+	Size       int64
+	K          int
+	M          int
+	W          int
+	PacketSize int
+	// BufferSize is the number of bytes of the file that were encoded
+	// at once, split over the k data blocks. It is the size of the
+	// file if the file was encoded at once.
+	BufferSize int64
+	// Technique is the name of the code, as accepted by NewCode.
+	Technique string
+	// Tech is the value of Technique in the Coding_Technique enum of
+	// jerasure.
+	Tech int
+	// Readins is the number of buffers that were encoded.
+	Readins int
+}
+
+// jerasureNames returns the names of the k+m blocks and of the meta file
+// of a file, in the Coding directory. The blocks are numbered from 1,
+// with as many digits as k has, and keep the extension of the file,
+// which starts at the first dot of its name.
+func jerasureNames(filename string, k, m int) (blocks []string, meta string) {
+	base, ext := path.Base(filename), ""
+	if dot := strings.IndexByte(base, '.'); dot >= 0 {
+		base, ext = base[:dot], base[dot:]
+	}
+	digits := len(strconv.Itoa(k))
+
+	blocks = make([]string, k+m)
+	for id := range blocks {
+		kind, index := "k", id+1
+		if id >= k {
+			kind, index = "m", id-k+1
+		}
+		blocks[id] = fmt.Sprintf("Coding/%s_%s%0*d%s", base, kind, digits, index, ext)
+	}
+	return blocks, fmt.Sprintf("Coding/%s_meta.txt", base)
+}
+
+// jerasureDecodedName returns the name of the file that the decoder
+// program of jerasure writes.
+func jerasureDecodedName(filename string) string {
+	base, ext := path.Base(filename), ""
+	if dot := strings.IndexByte(base, '.'); dot >= 0 {
+		base, ext = base[:dot], base[dot:]
+	}
+	return fmt.Sprintf("Coding/%s_decoded%s", base, ext)
+}
+
+// ReadJerasureMeta reads the meta file that the encoder program of
+// jerasure wrote for a file, from the ShardStore selected with
+// WithStore.
+func ReadJerasureMeta(filename string, opts ...Option) (*JerasureMeta, error) {
+	o := newOptions(opts)
+	_, name := jerasureNames(filename, 1, 0)
+	shard, err := o.store.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer shard.Close()
+
+	meta := new(JerasureMeta)
+	if _, err = fmt.Fscan(shard, &meta.Filename, &meta.Size, &meta.K, &meta.M, &meta.W, &meta.PacketSize,
+		&meta.BufferSize, &meta.Technique, &meta.Tech, &meta.Readins); err != nil {
+		return nil, fmt.Errorf("%w: malformed %s: %v", ErrInvalidParams, name, err)
+	}
+	if tech, ok := jerasureTechniques[meta.Technique]; !ok || tech != meta.Tech {
+		return nil, fmt.Errorf("%w: %q with technique %d", ErrUnknownTechnique, meta.Technique, meta.Tech)
+	}
+	if meta.K < 1 || meta.M < 0 || meta.Size < 0 || meta.BufferSize < 1 || meta.Readins < 1 {
+		return nil, fmt.Errorf("%w: %s holds invalid parameters", ErrInvalidParams, name)
+	}
+	return meta, nil
+}
+
+// jerasureBufferSize rounds a buffer size to a multiple of the coding
+// parameters the way the encoder program of jerasure does. Bitmatrix
+// codes round up, while matrix codes, without packets, take the closest
+// multiple, which may be zero.
+func jerasureBufferSize(bufferSize, multiple int64, packetSize int) int64 {
+	if bufferSize%multiple == 0 {
+		return bufferSize
+	}
+	up := roundUp(bufferSize, multiple)
+	if packetSize != 0 || up-bufferSize <= bufferSize-(up-multiple) {
+		return up
+	}
+	return up - multiple
+}
+
+// writeJerasureMeta writes the meta file of a file.
+func writeJerasureMeta(store ShardStore, meta *JerasureMeta, sync SyncMode) error {
+	_, name := jerasureNames(meta.Filename, meta.K, meta.M)
+	buf := fmt.Sprintf("%s\n%d\n%d %d %d %d %d\n%s\n%d\n%d\n", meta.Filename, meta.Size,
+		meta.K, meta.M, meta.W, meta.PacketSize, meta.BufferSize, meta.Technique, meta.Tech, meta.Readins)
+	return writeShard(store, name, []byte(buf), sync)
+}
+
+// EncodeJerasure encodes a file the way the encoder program of jerasure
+// does, so that it can be decoded by the decoder program of jerasure.
+// The file is read from, and the blocks written to, the ShardStore
+// selected with WithStore, which takes the place of the working
+// directory of the encoder.
+//
+// The blocks are written to Coding/<name>_k1.<ext> through
+// Coding/<name>_m<m>.<ext>, along with Coding/<name>_meta.txt. The file
+// is read bufferSize bytes at a time, which are split over the k data
+// blocks, or all at once if bufferSize is zero. The buffer size is
+// rounded to a multiple of the coding parameters, and the file is padded
+// with ASCII zeros to a multiple of the buffer size. A file that is read
+// at once is padded with zero bytes instead, where the encoder leaves
+// the padding uninitialised.
+func EncodeJerasure(filename, technique string, k, m, w, packetSize int, bufferSize int64, opts ...Option) error {
+	o := newOptions(opts)
+	tech, ok := jerasureTechniques[technique]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownTechnique, technique)
+	}
+	if err := checkArgs(k, m, w, packetSize, bufferSize); err != nil {
+		return err
+	}
+
+	size, err := o.store.Stat(filename)
+	if err != nil {
+		return err
+	}
+	if size == 0 {
+		return ErrNoData
+	}
+
+	// Pad the file like the encoder does
+	multiple := int64(jerasureIntSize) * int64(k) * int64(w) * int64(max(packetSize, 1))
+	padded := roundUp(size, multiple)
+	bufferSize = jerasureBufferSize(bufferSize, multiple, packetSize)
+	if bufferSize != 0 {
+		padded = roundUp(padded, bufferSize)
+	}
+	meta := &JerasureMeta{
+		Filename:   filename,
+		Size:       size,
+		K:          k,
+		M:          m,
+		W:          w,
+		PacketSize: packetSize,
+		BufferSize: bufferSize,
+		Technique:  technique,
+		Tech:       tech,
+		Readins:    1,
+	}
+	blockSize := padded / int64(k)
+	if bufferSize != 0 && size > bufferSize {
+		meta.Readins = int(padded / bufferSize)
+		blockSize = bufferSize / int64(k)
+	} else {
+		meta.BufferSize = size
+	}
+
+	code, err := NewCode(technique, k, m, w, packetSize, blockSize)
+	if err != nil {
+		return err
+	}
+	defer code.Close()
+
+	src, err := o.store.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	names, _ := jerasureNames(filename, k, m)
+	ids := make([]int, k+m)
+	for id := range ids {
+		ids[id] = id
+	}
+	bw, err := newShardBlockWriter(o.store, names, k, ids, o.sync)
+	if err != nil {
+		return err
+	}
+	defer bw.Close()
+
+	buf := make([]byte, int64(k)*blockSize)
+	data := make([][]byte, k)
+	for i := range data {
+		data[i] = buf[int64(i)*blockSize : int64(i+1)*blockSize]
+	}
+	coding := allocateBuffers(m, blockSize)
+
+	for r := 0; r < meta.Readins; r++ {
+		n, _, err := readBuffer(src, buf)
+		if err != nil {
+			return err
+		}
+		if meta.Readins > 1 {
+			copy(buf[n:], bytes.Repeat([]byte{'0'}, len(buf)-n))
+		}
+
+		if err = code.Encode(data, coding); err != nil {
+			return err
+		}
+		if err = bw.Data(data); err != nil {
+			return err
+		}
+		if err = bw.Coding(coding); err != nil {
+			return err
+		}
+	}
+	if err = bw.Commit(); err != nil {
+		return err
+	}
+	return writeJerasureMeta(o.store, meta, o.sync)
+}
+
+// DecodeJerasure decodes a file encoded by the encoder program of
+// jerasure, or by EncodeJerasure, the way the decoder program of
+// jerasure does. The blocks are read from, and the decoded file is
+// written to, the ShardStore selected with WithStore.
+//
+// Blocks that are missing or of the wrong size are erased. The decoded
+// file is written to Coding/<name>_decoded.<ext>.
+func DecodeJerasure(filename string, opts ...Option) error {
+	o := newOptions(opts)
+	meta, err := ReadJerasureMeta(filename, opts...)
+	if err != nil {
+		return err
+	}
+	k, m := meta.K, meta.M
+	names, _ := jerasureNames(filename, k, m)
+
+	blocks, erasures := loadBlocks(o, names, nil, make([]bool, k+m))
+	defer closeBlocks(blocks)
+
+	// A file that was encoded at once has blocks of the padded size,
+	// which is not recorded
+	blockSize := meta.BufferSize / int64(k)
+	if meta.BufferSize == meta.Size {
+		multiple := int64(jerasureIntSize) * int64(k) * int64(meta.W) * int64(max(meta.PacketSize, 1))
+		blockSize = roundUp(meta.Size, multiple) / int64(k)
+	}
+	num, err := numErasures(erasures)
+	if err != nil {
+		return err
+	}
+	for id, block := range blocks {
+		if block != nil && block.Len() != blockSize*int64(meta.Readins) {
+			o.logf("Corrupt file: %s: %d bytes", names[id], block.Len())
+			block.(io.Closer).Close()
+			blocks[id] = nil
+			erasures[num], erasures[num+1] = id, -1
+			num++
+		}
+	}
+	if num > m {
+		return fmt.Errorf("%w: found %d erasures with only %d parities", ErrTooManyErasures, num, m)
+	}
+
+	code, err := NewCode(meta.Technique, k, m, meta.W, meta.PacketSize, blockSize)
+	if err != nil {
+		return err
+	}
+	defer code.Close()
+
+	dst, err := o.store.Create(jerasureDecodedName(filename))
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	data := allocateBuffers(k, blockSize)
+	coding := allocateBuffers(m, blockSize)
+	remaining := meta.Size
+
+	for r := 0; r < meta.Readins; r++ {
+		for id, block := range blocks {
+			if block == nil {
+				continue
+			}
+			var buf []byte
+			if id < k {
+				buf = data[id]
+			} else {
+				buf = coding[id-k]
+			}
+			if _, err = io.ReadFull(block, buf); err != nil {
+				return err
+			}
+		}
+		if num > 0 {
+			if err = code.Decode(data, coding, erasures); err != nil {
+				return err
+			}
+		}
+
+		// Strip the padding from the end of the file
+		for _, buf := range data {
+			n := min(int64(len(buf)), remaining)
+			if _, err = dst.Write(buf[:n]); err != nil {
+				return err
+			}
+			remaining -= n
+		}
+	}
+	if o.sync != SyncNone {
+		if err = dst.Sync(); err != nil {
+			return err
+		}
+	}
+	return dst.Commit()
+}
@@ -0,0 +1,221 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// jerasureFixtures describes the files in testfiles/jerasure, which are
+// laid out like the working directory of the jerasure encoder program
+// after it encoded input.txt with the given buffer size. The encoder
+// rounds the buffer sizes of 1000 and 150 to 1024 and 128. The fixtures
+// are regenerated with testfiles/jerasure/generate.sh.
+var jerasureFixtures = []struct {
+	bufferSize int64
+	meta       JerasureMeta
+}{
+	{0, JerasureMeta{"input.txt", 3000, 4, 2, 8, 8, 3000, CauchyGood, 3, 1}},
+	{1000, JerasureMeta{"input.txt", 3000, 4, 2, 8, 8, 1024, CauchyOrig, 2, 3}},
+	{768, JerasureMeta{"input.txt", 2000, 3, 2, 8, 0, 768, ReedSolVan, 0, 3}},
+	{150, JerasureMeta{"input.txt", 1000, 4, 2, 8, 0, 128, ReedSolR6, 1, 8}},
+	{0, JerasureMeta{"input.txt", 5000, 10, 2, 11, 8, 5000, Liberation, 4, 1}},
+}
+
+// copyFixture copies a fixture directory to a temporary directory.
+func copyFixture(t *testing.T, technique string) string {
+	dir := t.TempDir()
+	src := filepath.Join("testfiles", "jerasure", technique)
+	err := filepath.Walk(src, func(name string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(src, name)
+		buf, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err = os.MkdirAll(filepath.Join(dir, filepath.Dir(rel)), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, rel), buf, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestJerasureBufferSize(t *testing.T) {
+	tests := []struct {
+		bufferSize, multiple int64
+		packetSize           int
+		want                 int64
+	}{
+		{1024, 1024, 8, 1024},
+		{1000, 1024, 8, 1024},
+		{1025, 1024, 8, 2048},
+		{150, 128, 0, 128},
+		{200, 128, 0, 256},
+		{192, 128, 0, 256},
+		{60, 128, 0, 0},
+	}
+	for _, test := range tests {
+		if got := jerasureBufferSize(test.bufferSize, test.multiple, test.packetSize); got != test.want {
+			t.Errorf("%d rounded to %d with packet size %d: expected %d, got %d", test.bufferSize, test.multiple, test.packetSize, test.want, got)
+		}
+	}
+}
+
+func TestJerasureNames(t *testing.T) {
+	blocks, meta := jerasureNames("dir/file.tar.gz", 10, 2)
+	if blocks[0] != "Coding/file_k01.tar.gz" || blocks[9] != "Coding/file_k10.tar.gz" || blocks[11] != "Coding/file_m02.tar.gz" {
+		t.Errorf("unexpected block names %v", blocks)
+	}
+	if meta != "Coding/file_meta.txt" {
+		t.Errorf("unexpected meta name %s", meta)
+	}
+	if blocks, _ = jerasureNames("file", 2, 1); !reflect.DeepEqual(blocks, []string{"Coding/file_k1", "Coding/file_k2", "Coding/file_m1"}) {
+		t.Errorf("unexpected block names %v", blocks)
+	}
+	if name := jerasureDecodedName("dir/file.tar.gz"); name != "Coding/file_decoded.tar.gz" {
+		t.Errorf("unexpected decoded name %s", name)
+	}
+}
+
+// TestJerasureEncode encodes the fixture inputs and compares every block
+// and meta file to the fixtures.
+func TestJerasureEncode(t *testing.T) {
+	for _, test := range jerasureFixtures {
+		fixture := test.meta
+		dir := t.TempDir()
+		input, err := os.ReadFile(filepath.Join("testfiles", "jerasure", fixture.Technique, fixture.Filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(filepath.Join(dir, fixture.Filename), input, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		err = EncodeJerasure(fixture.Filename, fixture.Technique, fixture.K, fixture.M, fixture.W, fixture.PacketSize,
+			test.bufferSize, WithStore(NewDirStore(dir)))
+		if err != nil {
+			t.Fatalf("%s: %v", fixture.Technique, err)
+		}
+
+		blocks, meta := jerasureNames(fixture.Filename, fixture.K, fixture.M)
+		for _, name := range append(blocks, meta) {
+			want, err := os.ReadFile(filepath.Join("testfiles", "jerasure", fixture.Technique, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s: %s differs from the fixture", fixture.Technique, name)
+			}
+		}
+	}
+}
+
+// TestJerasureDecode decodes the fixtures with up to m blocks erased.
+func TestJerasureDecode(t *testing.T) {
+	for _, test := range jerasureFixtures {
+		fixture := test.meta
+		blocks, _ := jerasureNames(fixture.Filename, fixture.K, fixture.M)
+		for _, erased := range [][]int{nil, {0}, {1, fixture.K}, {fixture.K - 1, fixture.K + fixture.M - 1}} {
+			dir := copyFixture(t, fixture.Technique)
+			store := NewDirStore(dir)
+			meta, err := ReadJerasureMeta(fixture.Filename, WithStore(store))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*meta, fixture) {
+				t.Fatalf("%s: expected meta %+v, got %+v", fixture.Technique, fixture, *meta)
+			}
+
+			for _, id := range erased {
+				store.Delete(blocks[id])
+			}
+			if err = DecodeJerasure(fixture.Filename, WithStore(store)); err != nil {
+				t.Fatalf("%s: erased %v: %v", fixture.Technique, erased, err)
+			}
+			input, _ := os.ReadFile(filepath.Join(dir, fixture.Filename))
+			decoded, err := os.ReadFile(filepath.Join(dir, jerasureDecodedName(fixture.Filename)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decoded, input) {
+				t.Errorf("%s: erased %v: decoded file differs from the original", fixture.Technique, erased)
+			}
+		}
+	}
+}
+
+// TestJerasureRoundTrip encodes and decodes with every technique through
+// a MemStore, with a truncated block and a missing block, both with a
+// buffer size and with the file encoded at once.
+func TestJerasureRoundTrip(t *testing.T) {
+	input := make([]byte, 10000)
+	for i := range input {
+		input[i] = byte(i * 7)
+	}
+	for _, bufferSize := range []int64{4096, 0} {
+		for _, technique := range []string{ReedSolVan, ReedSolR6, CauchyOrig, CauchyGood, Liberation, BlaumRoth, Liber8tion} {
+			testJerasureRoundTrip(t, input, technique, bufferSize)
+		}
+	}
+
+	if err := EncodeJerasure("file", "rdp", 4, 2, 8, 8, 0, WithStore(NewMemStore())); !errors.Is(err, ErrUnknownTechnique) {
+		t.Errorf("expected ErrUnknownTechnique, got %v", err)
+	}
+}
+
+func testJerasureRoundTrip(t *testing.T, input []byte, technique string, bufferSize int64) {
+	w := map[string]int{Liberation: 7, BlaumRoth: 6}[technique]
+	if w == 0 {
+		w = 8
+	}
+	store := NewMemStore()
+	writeTestShard(t, store, "data/file.bin", input)
+	if err := EncodeJerasure("data/file.bin", technique, 4, 2, w, 16, bufferSize, WithStore(store)); err != nil {
+		t.Fatalf("%s: buffer size %d: %v", technique, bufferSize, err)
+	}
+
+	writeTestShard(t, store, "Coding/file_k2.bin", []byte("short"))
+	store.Delete("Coding/file_m1.bin")
+	if err := DecodeJerasure("data/file.bin", WithStore(store)); err != nil {
+		t.Fatalf("%s: buffer size %d: %v", technique, bufferSize, err)
+	}
+	decoded, err := readTestShard(store, "Coding/file_decoded.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, input) {
+		t.Errorf("%s: buffer size %d: decoded file differs from the original", technique, bufferSize)
+	}
+
+	store.Delete("Coding/file_k1.bin")
+	if err = DecodeJerasure("data/file.bin", WithStore(store)); !errors.Is(err, ErrTooManyErasures) {
+		t.Errorf("%s: buffer size %d: expected ErrTooManyErasures, got %v", technique, bufferSize, err)
+	}
+}
@@ -0,0 +1,91 @@
+//go:build linux && cgo && !purego
+// +build linux,cgo,!purego
+
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+)
+
+// residentSize returns the resident set size of the process in bytes.
+func residentSize(t *testing.T) int64 {
+	buf, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		t.Skip("cannot read the resident set size:", err)
+	}
+	var size, resident int64
+	if _, err = fmt.Sscan(string(buf), &size, &resident); err != nil {
+		t.Fatal(err)
+	}
+	return resident * int64(os.Getpagesize())
+}
+
+// TestCloseLeak creates many codes and ensures that the C memory of their
+// matrices and schedules is released, both by Close and by the
+// finalizer of codes that are not closed. Every code holds tens of KiB
+// of C memory, so a leak grows the process by more than 64 MiB.
+func TestCloseLeak(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping leak test in short mode")
+	}
+	const codes = 2000
+	const limit = 16 << 20
+
+	newCode := func() Coder {
+		code, err := NewCauchyGoodCode(10, 4, 8, 8, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	// Warm up the allocator and the galois field tables
+	for i := 0; i < 100; i++ {
+		newCode().Close()
+	}
+	runtime.GC()
+
+	before := residentSize(t)
+	for i := 0; i < codes; i++ {
+		code := newCode()
+		if err := code.Close(); err != nil {
+			t.Fatal(err)
+		}
+		// Closing twice is harmless
+		if err := code.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if grown := residentSize(t) - before; grown > limit {
+		t.Errorf("closed codes leaked %d bytes", grown)
+	}
+
+	before = residentSize(t)
+	for i := 0; i < codes; i++ {
+		newCode()
+		if i%100 == 0 {
+			runtime.GC()
+		}
+	}
+	runtime.GC()
+	if grown := residentSize(t) - before; grown > limit {
+		t.Errorf("garbage collected codes leaked %d bytes", grown)
+	}
+}
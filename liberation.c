@@ -1,3 +1,6 @@
+//go:build linux && !purego
+// +build linux,!purego
+
 /* liberation.c
  * James S. Plank
 
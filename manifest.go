@@ -0,0 +1,238 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// ManifestVersion is the version of the manifest written by Encode.
+// Manifests of other versions are rejected.
+const ManifestVersion = 2
+
+// Manifest describes a stripe, so that it can be decoded without
+// knowing how it was encoded. Encode writes it next to the blocks as
+// <stripe>_meta.
+type Manifest struct {
+	// Version is the version of the manifest format.
+	Version int `json:"version"`
+	// Technique is the name of the code, as accepted by NewCode.
+	Technique  string `json:"technique"`
+	K          int    `json:"k"`
+	M          int    `json:"m"`
+	W          int    `json:"w"`
+	PacketSize int    `json:"packetsize"`
+	BufferSize int64  `json:"buffersize"`
+	// Size is the length of every data block before it was padded to a
+	// multiple of the buffer size.
+	Size int64 `json:"size"`
+	// Hash is the name of the hash used for the checksums, as registered
+	// with RegisterHash.
+	Hash string `json:"hash"`
+	// Naming is the template that names the blocks, if it is not
+	// DefaultNaming.
+	Naming string `json:"naming,omitempty"`
+	// Placement holds the root of every block, data blocks first, if
+	// the blocks were placed on several roots with WithRoots.
+	Placement []string `json:"placement,omitempty"`
+	// Blocks holds the checksums of every block, data blocks first.
+	Blocks []BlockChecksums `json:"blocks"`
+}
+
+// BlockChecksums holds the hex encoded checksums of a block.
+type BlockChecksums struct {
+	// Checksum is the checksum of the whole block as it is stored.
+	Checksum string `json:"checksum"`
+	// Buffers holds the checksum of every buffer of the block. Only the
+	// unpadded part of the last buffer of a data block is included.
+	Buffers []string `json:"buffers,omitempty"`
+}
+
+// newManifest returns a manifest describing a stripe of blocks of the
+// given size, encoded with code and checksummed with the named hash.
+func newManifest(code Coder, size int64, hash string) *Manifest {
+	return &Manifest{
+		Version:    ManifestVersion,
+		Technique:  code.Technique(),
+		K:          code.K(),
+		M:          code.M(),
+		W:          code.W(),
+		PacketSize: code.PacketSize(),
+		BufferSize: code.Buffersize(),
+		Size:       size,
+		Hash:       hash,
+		Blocks:     make([]BlockChecksums, code.K()+code.M()),
+	}
+}
+
+// Coder returns a new code with the parameters in the manifest.
+func (this *Manifest) Coder() (Coder, error) {
+	return NewCode(this.Technique, this.K, this.M, this.W, this.PacketSize, this.BufferSize)
+}
+
+// check ensures that a code has the parameters recorded in the manifest.
+// A code without a buffer size gets it from the size of the blocks, the
+// same way as when the stripe was encoded.
+func (this *Manifest) check(code Coder) error {
+	if code.Technique() != this.Technique || code.K() != this.K || code.M() != this.M ||
+		code.W() != this.W || code.PacketSize() != this.PacketSize || (code.Buffersize() != 0 && code.Buffersize() != this.BufferSize) {
+		return fmt.Errorf("%w: the stripe was encoded with %s k=%d m=%d w=%d packetsize=%d buffersize=%d",
+			ErrInvalidParams, this.Technique, this.K, this.M, this.W, this.PacketSize, this.BufferSize)
+	}
+	return nil
+}
+
+// manifestName returns the name of the manifest of a stripe.
+func manifestName(stripeName string) string {
+	return stripeName + "_meta"
+}
+
+// writeManifest writes the manifest of a stripe to store, on every root
+// of its placement.
+func writeManifest(store ShardStore, stripeName string, manifest *Manifest, sync SyncMode) error {
+	buf, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	for _, name := range manifestNames(stripeName, manifest.Placement) {
+		if err = writeShard(store, name, buf, sync); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadManifest reads the manifest of a stripe, from the ShardStore
+// selected with WithStore, or from the first of the roots selected with
+// WithRoots that holds a copy. It returns ErrNoManifest if the stripe
+// was encoded without one.
+func ReadManifest(stripeName string, opts ...Option) (*Manifest, error) {
+	o := newOptions(opts)
+	manifest, _, err := readStripeManifest(o.store, stripeName, o.roots)
+	return manifest, err
+}
+
+// readManifest reads the manifest of a stripe from store.
+func readManifest(store ShardStore, stripeName string) (*Manifest, error) {
+	shard, err := store.Open(manifestName(stripeName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNoManifest
+	} else if err != nil {
+		return nil, err
+	}
+	buf, err := io.ReadAll(shard)
+	shard.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := new(Manifest)
+	if err = json.Unmarshal(buf, manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Version != ManifestVersion {
+		return nil, fmt.Errorf("%w: manifest version %d", ErrInvalidParams, manifest.Version)
+	}
+	if len(manifest.Blocks) != manifest.K+manifest.M {
+		return nil, fmt.Errorf("%w: %d checksums for %d blocks", ErrInvalidParams, len(manifest.Blocks), manifest.K+manifest.M)
+	}
+	return manifest, nil
+}
+
+// verifyBlock checks the size and buffer checksums of block id against
+// the manifest and rewinds it. Blocks without buffer checksums are only
+// checked for their size.
+func (this *Manifest) verifyBlock(block io.ReadSeeker, id int) error {
+	want := this.Size
+	if id >= this.K {
+		want = roundUp(this.Size, this.BufferSize)
+	}
+
+	size, err := block.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if size != want {
+		return fmt.Errorf("%w: block is %d bytes, expected %d", ErrBlocksUnequal, size, want)
+	}
+	if _, err = block.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	sums := this.Blocks[id].Buffers
+	if len(sums) == 0 {
+		return nil
+	}
+	h, err := newHash(this.Hash)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, this.BufferSize)
+	for i, sum := range sums {
+		n, _, err := readBuffer(block, buf)
+		if err != nil {
+			return err
+		}
+		if sumBuffer(h, buf[:n]) != sum {
+			return fmt.Errorf("%w: buffer %d", ErrChecksumMismatch, i)
+		}
+	}
+
+	_, err = block.Seek(0, io.SeekStart)
+	return err
+}
+
+// DecodeStripe regenerates the erased blocks of a stripe, using the code
+// described by its manifest.
+func DecodeStripe(stripeName string, opts ...Option) error {
+	manifest, err := ReadManifest(stripeName, opts...)
+	if err != nil {
+		return err
+	}
+	code, err := manifest.Coder()
+	if err != nil {
+		return err
+	}
+	defer code.Close()
+	return Decode(stripeName, code, opts...)
+}
+
+// VerifyStripe checks the blocks of a stripe against its manifest, with
+// the options that Decode takes, and returns the ids of the blocks that
+// are missing or corrupt. Data blocks come first.
+func VerifyStripe(stripeName string, opts ...Option) ([]int, error) {
+	o := newOptions(opts)
+	manifest, available, err := readStripeManifest(o.store, stripeName, o.roots)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := newStripeLayout(o, stripeName, manifest.K, manifest.M, manifest, available)
+	if err != nil {
+		return nil, err
+	}
+	blocks, erasures := loadBlocks(o, layout.names, manifest, layout.lost)
+	closeBlocks(blocks)
+
+	num, err := numErasures(erasures)
+	if err != nil {
+		return nil, err
+	}
+	return erasures[:num], nil
+}
@@ -0,0 +1,175 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	k, m := 4, 2
+	code, err := NewCauchyGoodCode(k, m, 8, 64, 16384)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stripeName, blocks := newTestStripe(t, k, 10000)
+	if err = Encode(stripeName, code); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := ReadManifest(stripeName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Version != ManifestVersion || manifest.Technique != CauchyGood || manifest.K != k ||
+		manifest.M != m || manifest.W != 8 || manifest.PacketSize != 64 || manifest.BufferSize != 16384 || manifest.Size != 10000 {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+
+	os.Remove(stripeName + "_k0")
+	os.Remove(stripeName + "_m1")
+	if err = os.WriteFile(stripeName+"_k2", make([]byte, 10000), 0644); err != nil {
+		t.Fatal(err)
+	}
+	damaged, err := VerifyStripe(stripeName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(damaged, []int{0, 2, k + 1}) {
+		t.Errorf("expected blocks 0, 2 and %d to be damaged, got %v", k+1, damaged)
+	}
+	if err = os.WriteFile(stripeName+"_k2", blocks[2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = DecodeStripe(stripeName); err != nil {
+		t.Fatal(err)
+	}
+	if damaged, err = VerifyStripe(stripeName); err != nil || len(damaged) != 0 {
+		t.Errorf("expected the stripe to be repaired, got %v, %v", damaged, err)
+	}
+	decoded, err := os.ReadFile(stripeName + "_k0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, blocks[0]) {
+		t.Error("decoded data block differs from the original")
+	}
+
+	// A code with different parameters must be rejected
+	other, err := NewCauchyGoodCode(k, m, 8, 128, 32768)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(stripeName + "_k0")
+	if err = Decode(stripeName, other); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("expected ErrInvalidParams, got %v", err)
+	}
+}
+
+func TestCorruptBlocks(t *testing.T) {
+	k, m := 4, 2
+	code, err := NewReedSolVanCode(k, m, 8, 0, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stripeName, blocks := newTestStripe(t, k, 3*4096+100)
+	if err = Encode(stripeName, code, WithHash(SHA256)); err != nil {
+		t.Fatal(err)
+	}
+	coding, err := os.ReadFile(stripeName + "_m1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip a bit in one block and truncate another
+	corrupt := append([]byte(nil), blocks[1]...)
+	corrupt[5000] ^= 0x01
+	if err = os.WriteFile(stripeName+"_k1", corrupt, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(stripeName+"_m1", coding[:4096], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = DecodeStripe(stripeName); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := os.ReadFile(stripeName + "_k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, blocks[1]) {
+		t.Error("corrupt data block was not repaired")
+	}
+	decoded, err = os.ReadFile(stripeName + "_m1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, coding) {
+		t.Error("truncated coding block was not repaired")
+	}
+}
+
+func TestManifestVersion(t *testing.T) {
+	code, err := NewReedSolVanCode(4, 2, 8, 0, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stripeName, _ := newTestStripe(t, 4, 4096)
+	if err = Encode(stripeName, code); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := ReadManifest(stripeName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, version := range []int{0, 1, ManifestVersion + 1} {
+		manifest.Version = version
+		if err = writeManifest(NewDirStore(""), stripeName, manifest, SyncNone); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = ReadManifest(stripeName); !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("version %d: expected ErrInvalidParams, got %v", version, err)
+		}
+	}
+}
+
+func TestNoManifest(t *testing.T) {
+	if err := DecodeStripe("testfiles/decoderTest"); !errors.Is(err, ErrNoManifest) {
+		t.Errorf("expected ErrNoManifest, got %v", err)
+	}
+	if _, err := NewCode("rdp", 4, 2, 8, 0, 0); !errors.Is(err, ErrUnknownTechnique) {
+		t.Errorf("expected ErrUnknownTechnique, got %v", err)
+	}
+
+	code, err := NewReedSolVanCode(4, 2, 8, 0, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stripeName, _ := newTestStripe(t, 4, 4096)
+	if err = Encode(stripeName, code, WithHash("md4")); !errors.Is(err, ErrUnknownHash) {
+		t.Errorf("expected ErrUnknownHash, got %v", err)
+	}
+}
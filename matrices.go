@@ -0,0 +1,281 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"fmt"
+)
+
+// Matrix is a matrix over GF(2^w), such as the coding matrix of a code.
+// Matrices are immutable; every operation returns a new matrix.
+type Matrix struct {
+	rows, cols, w int
+	// elements holds the matrix row by row.
+	elements []int
+}
+
+// NewMatrix returns a matrix over GF(2^w) with the given rows. All rows
+// have to be of the same length and every element has to be in the
+// field.
+func NewMatrix(rows [][]int, w int) (*Matrix, error) {
+	if w < 1 || w > 32 {
+		return nil, fmt.Errorf("%w: word size must be between 1 and 32", ErrInvalidParams)
+	}
+	elements, cols, err := flatten(rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range elements {
+		if e < 0 || int64(e) >= 1<<uint(w) {
+			return nil, fmt.Errorf("%w: element %d is not in GF(2^%d)", ErrInvalidParams, e, w)
+		}
+	}
+	return &Matrix{len(rows), cols, w, elements}, nil
+}
+
+// CodingMatrix returns the m x k coding matrix of a code. Codes that
+// encode with a bitmatrix, such as the Cauchy and Liberation codes, have
+// no coding matrix; use CodingBitMatrix for them.
+func CodingMatrix(code Coder) (*Matrix, error) {
+	c, ok := code.(interface{ codingMatrix() []int })
+	if !ok {
+		return nil, fmt.Errorf("%w: %s codes have no coding matrix", ErrInvalidParams, code.Technique())
+	}
+	matrix := c.codingMatrix()
+	if matrix == nil {
+		return nil, ErrClosed
+	}
+	return &Matrix{code.M(), code.K(), code.W(), matrix}, nil
+}
+
+// Rows returns the number of rows of the matrix.
+func (this *Matrix) Rows() int {
+	return this.rows
+}
+
+// Cols returns the number of columns of the matrix.
+func (this *Matrix) Cols() int {
+	return this.cols
+}
+
+// W returns the word size of the field of the matrix.
+func (this *Matrix) W() int {
+	return this.w
+}
+
+// At returns the element in row i and column j.
+func (this *Matrix) At(i, j int) int {
+	return this.elements[i*this.cols+j]
+}
+
+// Elements returns a copy of the rows of the matrix.
+func (this *Matrix) Elements() [][]int {
+	return unflatten(this.elements, this.rows, this.cols)
+}
+
+// Mul returns the product of the matrix and other, the way
+// jerasure_matrix_multiply computes it. Both matrices have to be over
+// the same field.
+func (this *Matrix) Mul(other *Matrix) (*Matrix, error) {
+	if this.w != other.w {
+		return nil, fmt.Errorf("%w: cannot multiply matrices over GF(2^%d) and GF(2^%d)", ErrInvalidParams, this.w, other.w)
+	}
+	if this.cols != other.rows {
+		return nil, fmt.Errorf("%w: cannot multiply a %dx%d by a %dx%d matrix", ErrInvalidParams, this.rows, this.cols, other.rows, other.cols)
+	}
+	product := make([]int, this.rows*other.cols)
+	for i := 0; i < this.rows; i++ {
+		for j := 0; j < other.cols; j++ {
+			for l := 0; l < this.cols; l++ {
+				product[i*other.cols+j] ^= galoisMultiply(this.At(i, l), other.At(l, j), this.w)
+			}
+		}
+	}
+	return &Matrix{this.rows, other.cols, this.w, product}, nil
+}
+
+// Invert returns the inverse of the matrix. It returns ErrNotInvertible
+// if the matrix is not square or is singular.
+func (this *Matrix) Invert() (*Matrix, error) {
+	if this.rows != this.cols {
+		return nil, fmt.Errorf("%w: %dx%d matrix is not square", ErrNotInvertible, this.rows, this.cols)
+	}
+	inv := invertMatrix(this.elements, this.rows, this.w)
+	if inv == nil {
+		return nil, ErrNotInvertible
+	}
+	return &Matrix{this.rows, this.cols, this.w, inv}, nil
+}
+
+// IsInvertible reports whether the matrix is square and non-singular.
+func (this *Matrix) IsInvertible() bool {
+	return this.rows == this.cols && invertMatrix(this.elements, this.rows, this.w) != nil
+}
+
+// ToBitMatrix expands the matrix into a bitmatrix, replacing every
+// element with a w x w block, the way jerasure_matrix_to_bitmatrix does.
+func (this *Matrix) ToBitMatrix() *BitMatrix {
+	bitmatrix := matrixToBitmatrix(this.cols, this.rows, this.w, this.elements)
+	return &BitMatrix{this.rows * this.w, this.cols * this.w, this.w, bitmatrix}
+}
+
+// String returns the matrix in the format of jerasure_print_matrix.
+func (this *Matrix) String() string {
+	return formatMatrix(this.elements, this.rows, this.cols, this.w)
+}
+
+// BitMatrix is a matrix over GF(2), made up of w x w blocks, such as the
+// coding bitmatrix of a code. Bitmatrices are immutable; every operation
+// returns a new bitmatrix.
+type BitMatrix struct {
+	rows, cols, w int
+	// bits holds the bitmatrix row by row, one bit per element.
+	bits []int
+}
+
+// NewBitMatrix returns a bitmatrix with the given rows of zeros and
+// ones. The number of rows and columns have to be multiples of w.
+func NewBitMatrix(rows [][]int, w int) (*BitMatrix, error) {
+	if w < 1 || w > 32 {
+		return nil, fmt.Errorf("%w: word size must be between 1 and 32", ErrInvalidParams)
+	}
+	bits, cols, err := flatten(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows)%w != 0 || cols%w != 0 {
+		return nil, fmt.Errorf("%w: a %dx%d bitmatrix is not made up of %dx%d blocks", ErrInvalidParams, len(rows), cols, w, w)
+	}
+	for _, b := range bits {
+		if b != 0 && b != 1 {
+			return nil, fmt.Errorf("%w: bitmatrix element %d is not a bit", ErrInvalidParams, b)
+		}
+	}
+	return &BitMatrix{len(rows), cols, w, bits}, nil
+}
+
+// CodingBitMatrix returns the mw x kw coding bitmatrix of a code. The
+// coding matrix of codes that encode with a matrix is expanded into a
+// bitmatrix.
+func CodingBitMatrix(code Coder) (*BitMatrix, error) {
+	if c, ok := code.(interface{ codingBitmatrix() []int }); ok {
+		bitmatrix := c.codingBitmatrix()
+		if bitmatrix == nil {
+			return nil, ErrClosed
+		}
+		k, m, w := code.K(), code.M(), code.W()
+		return &BitMatrix{m * w, k * w, w, bitmatrix}, nil
+	}
+	matrix, err := CodingMatrix(code)
+	if err != nil {
+		return nil, err
+	}
+	return matrix.ToBitMatrix(), nil
+}
+
+// Rows returns the number of rows of the bitmatrix.
+func (this *BitMatrix) Rows() int {
+	return this.rows
+}
+
+// Cols returns the number of columns of the bitmatrix.
+func (this *BitMatrix) Cols() int {
+	return this.cols
+}
+
+// W returns the size of the blocks of the bitmatrix.
+func (this *BitMatrix) W() int {
+	return this.w
+}
+
+// At returns the bit in row i and column j.
+func (this *BitMatrix) At(i, j int) int {
+	return this.bits[i*this.cols+j]
+}
+
+// Elements returns a copy of the rows of the bitmatrix.
+func (this *BitMatrix) Elements() [][]int {
+	return unflatten(this.bits, this.rows, this.cols)
+}
+
+// Mul returns the product of the bitmatrix and other over GF(2). Both
+// bitmatrices have to be made up of blocks of the same size.
+func (this *BitMatrix) Mul(other *BitMatrix) (*BitMatrix, error) {
+	if this.w != other.w {
+		return nil, fmt.Errorf("%w: cannot multiply bitmatrices of %dx%d and %dx%d blocks", ErrInvalidParams, this.w, this.w, other.w, other.w)
+	}
+	if this.cols != other.rows {
+		return nil, fmt.Errorf("%w: cannot multiply a %dx%d by a %dx%d bitmatrix", ErrInvalidParams, this.rows, this.cols, other.rows, other.cols)
+	}
+	product := make([]int, this.rows*other.cols)
+	for i := 0; i < this.rows; i++ {
+		for j := 0; j < other.cols; j++ {
+			for l := 0; l < this.cols; l++ {
+				product[i*other.cols+j] ^= this.At(i, l) & other.At(l, j)
+			}
+		}
+	}
+	return &BitMatrix{this.rows, other.cols, this.w, product}, nil
+}
+
+// Invert returns the inverse of the bitmatrix. It returns
+// ErrNotInvertible if the bitmatrix is not square or is singular.
+func (this *BitMatrix) Invert() (*BitMatrix, error) {
+	if this.rows != this.cols {
+		return nil, fmt.Errorf("%w: %dx%d bitmatrix is not square", ErrNotInvertible, this.rows, this.cols)
+	}
+	inv := invertBitmatrix(this.bits, this.rows)
+	if inv == nil {
+		return nil, ErrNotInvertible
+	}
+	return &BitMatrix{this.rows, this.cols, this.w, inv}, nil
+}
+
+// IsInvertible reports whether the bitmatrix is square and non-singular.
+func (this *BitMatrix) IsInvertible() bool {
+	return this.rows == this.cols && invertBitmatrix(this.bits, this.rows) != nil
+}
+
+// String returns the bitmatrix in the format of
+// jerasure_print_bitmatrix.
+func (this *BitMatrix) String() string {
+	return formatBitmatrix(this.bits, this.rows, this.cols, this.w)
+}
+
+// flatten joins the rows of a matrix, which all have to be of the same
+// non-zero length.
+func flatten(rows [][]int) (elements []int, cols int, err error) {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil, 0, fmt.Errorf("%w: matrix is empty", ErrInvalidParams)
+	}
+	cols = len(rows[0])
+	elements = make([]int, 0, len(rows)*cols)
+	for i, row := range rows {
+		if len(row) != cols {
+			return nil, 0, fmt.Errorf("%w: row %d has %d elements, expected %d", ErrInvalidParams, i, len(row), cols)
+		}
+		elements = append(elements, row...)
+	}
+	return elements, cols, nil
+}
+
+// unflatten splits the elements of a matrix into rows.
+func unflatten(elements []int, rows, cols int) [][]int {
+	matrix := make([][]int, rows)
+	for i := range matrix {
+		matrix[i] = append([]int(nil), elements[i*cols:(i+1)*cols]...)
+	}
+	return matrix
+}
@@ -0,0 +1,129 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMatrix(t *testing.T) {
+	for _, w := range []int{8, 16, 32} {
+		code, err := NewReedSolVanCode(3, 3, w, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matrix, err := CodingMatrix(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matrix.Rows() != 3 || matrix.Cols() != 3 || matrix.W() != w {
+			t.Fatalf("w=%d: unexpected %dx%d matrix over GF(2^%d)", w, matrix.Rows(), matrix.Cols(), matrix.W())
+		}
+
+		// Every square part of an MDS coding matrix is invertible
+		if !matrix.IsInvertible() {
+			t.Fatalf("w=%d: coding matrix is not invertible", w)
+		}
+		inv, err := matrix.Invert()
+		if err != nil {
+			t.Fatal(err)
+		}
+		product, err := matrix.Mul(inv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		identity, _ := NewMatrix([][]int{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}, w)
+		if !reflect.DeepEqual(product, identity) {
+			t.Errorf("w=%d: matrix times its inverse is\n%s", w, product)
+		}
+
+		// The bitmatrix of the inverse is the inverse of the bitmatrix
+		bitInv, err := matrix.ToBitMatrix().Invert()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(bitInv, inv.ToBitMatrix()) {
+			t.Errorf("w=%d: inverse bitmatrix does not match the inverse matrix", w)
+		}
+		bitProduct, err := bitInv.Mul(matrix.ToBitMatrix())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(bitProduct, identity.ToBitMatrix()) {
+			t.Errorf("w=%d: bitmatrix times its inverse is\n%s", w, bitProduct)
+		}
+	}
+}
+
+func TestMatrixErrors(t *testing.T) {
+	if _, err := NewMatrix([][]int{{1, 2}, {3}}, 8); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("ragged rows: expected ErrInvalidParams, got %v", err)
+	}
+	if _, err := NewMatrix([][]int{{1, 256}}, 8); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("element out of field: expected ErrInvalidParams, got %v", err)
+	}
+	if _, err := NewBitMatrix([][]int{{1, 0, 1}, {0, 1, 0}}, 2); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("partial blocks: expected ErrInvalidParams, got %v", err)
+	}
+
+	singular, _ := NewMatrix([][]int{{1, 2}, {2, 4}}, 8)
+	if singular.IsInvertible() {
+		t.Errorf("singular matrix reported as invertible")
+	}
+	if _, err := singular.Invert(); !errors.Is(err, ErrNotInvertible) {
+		t.Errorf("singular matrix: expected ErrNotInvertible, got %v", err)
+	}
+	wide, _ := NewMatrix([][]int{{1, 2, 3}}, 8)
+	if _, err := wide.Invert(); !errors.Is(err, ErrNotInvertible) {
+		t.Errorf("wide matrix: expected ErrNotInvertible, got %v", err)
+	}
+	if _, err := wide.Mul(wide); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("mismatched product: expected ErrInvalidParams, got %v", err)
+	}
+
+	code, err := NewLiberationCode(3, 2, 7, 8, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = CodingMatrix(code); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("bitmatrix code: expected ErrInvalidParams, got %v", err)
+	}
+	bitmatrix, err := CodingBitMatrix(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bitmatrix.Rows() != 14 || bitmatrix.Cols() != 21 {
+		t.Errorf("unexpected %dx%d Liberation bitmatrix", bitmatrix.Rows(), bitmatrix.Cols())
+	}
+}
+
+func TestMatrixString(t *testing.T) {
+	matrix, _ := NewMatrix([][]int{{1, 1}, {1, 2}}, 8)
+	if s, want := matrix.String(), "  1   1\n  1   2\n"; s != want {
+		t.Errorf("expected matrix\n%q, got\n%q", want, s)
+	}
+
+	bitmatrix := matrix.ToBitMatrix()
+	want := "10 10\n01 01\n\n10 01\n01 11\n"
+	small, _ := NewMatrix([][]int{{1, 1}, {1, 2}}, 2)
+	if s := small.ToBitMatrix().String(); s != want {
+		t.Errorf("expected bitmatrix\n%q, got\n%q", want, s)
+	}
+	if bitmatrix.Rows() != 16 || bitmatrix.Cols() != 16 {
+		t.Errorf("unexpected %dx%d bitmatrix", bitmatrix.Rows(), bitmatrix.Cols())
+	}
+}
@@ -0,0 +1,601 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+// This file builds the coding matrices and bitmatrices of jerasure in Go,
+// following reed_sol.c, cauchy.c, liberation.c and jerasure.c, so that
+// the pure Go codes produce the same bytes as the C library.
+
+import (
+	"github.com/jsgilmore/goerasure/gf"
+)
+
+// field returns GF(2^w). The word size must have been validated.
+func field(w int) *gf.GF {
+	f, err := gf.Field(w)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// galoisMultiply multiplies x and y in GF(2^w).
+func galoisMultiply(x, y, w int) int {
+	return int(field(w).Mul(uint32(x), uint32(y)))
+}
+
+// galoisDivide divides x by y in GF(2^w).
+func galoisDivide(x, y, w int) int {
+	return int(field(w).Div(uint32(x), uint32(y)))
+}
+
+// reedSolVandermondeMatrix returns the m x k coding matrix of a
+// Reed-Solomon code, taken from the bottom of a systematic Vandermonde
+// distribution matrix.
+func reedSolVandermondeMatrix(k, m, w int) []int {
+	vdm := reedSolBigVandermondeMatrix(k+m, k, w)
+	if vdm == nil {
+		return nil
+	}
+	return vdm[k*k:]
+}
+
+// reedSolExtendedVandermondeMatrix returns a rows x cols extended
+// Vandermonde matrix, whose first and last rows are unit vectors.
+func reedSolExtendedVandermondeMatrix(rows, cols, w int) []int {
+	if w < 30 && (1<<uint(w) < rows || 1<<uint(w) < cols) {
+		return nil
+	}
+
+	vdm := make([]int, rows*cols)
+	vdm[0] = 1
+	if rows == 1 {
+		return vdm
+	}
+	vdm[rows*cols-1] = 1
+	if rows == 2 {
+		return vdm
+	}
+
+	for i := 1; i < rows-1; i++ {
+		x := 1
+		for j := 0; j < cols; j++ {
+			vdm[i*cols+j] = x
+			x = galoisMultiply(x, i, w)
+		}
+	}
+	return vdm
+}
+
+// reedSolBigVandermondeMatrix turns an extended Vandermonde matrix into a
+// distribution matrix with an identity in its first cols rows, a first
+// coding row of ones and a first coding column of ones.
+func reedSolBigVandermondeMatrix(rows, cols, w int) []int {
+	if cols >= rows {
+		return nil
+	}
+	dist := reedSolExtendedVandermondeMatrix(rows, cols, w)
+	if dist == nil {
+		return nil
+	}
+
+	for i := 1; i < cols; i++ {
+		// Find a row where element i is not zero and swap it into row i
+		j := i
+		for j < rows && dist[j*cols+i] == 0 {
+			j++
+		}
+		if j >= rows {
+			return nil
+		}
+		if j != i {
+			for x := 0; x < cols; x++ {
+				dist[i*cols+x], dist[j*cols+x] = dist[j*cols+x], dist[i*cols+x]
+			}
+		}
+
+		// Scale column i so that element i,i is one
+		if e := dist[i*cols+i]; e != 1 {
+			inv := galoisDivide(1, e, w)
+			for x := 0; x < rows; x++ {
+				dist[x*cols+i] = galoisMultiply(inv, dist[x*cols+i], w)
+			}
+		}
+
+		// Zero the rest of row i by adding multiples of column i to
+		// the other columns
+		for j := 0; j < cols; j++ {
+			e := dist[i*cols+j]
+			if j != i && e != 0 {
+				for x := 0; x < rows; x++ {
+					dist[x*cols+j] ^= galoisMultiply(e, dist[x*cols+i], w)
+				}
+			}
+		}
+	}
+
+	// Make the first coding row all ones by scaling the coding part of
+	// each column
+	for j := 0; j < cols; j++ {
+		if e := dist[cols*cols+j]; e != 1 {
+			inv := galoisDivide(1, e, w)
+			for x := cols; x < rows; x++ {
+				dist[x*cols+j] = galoisMultiply(inv, dist[x*cols+j], w)
+			}
+		}
+	}
+
+	// Make the first column of the other coding rows ones by scaling the
+	// rows
+	for i := cols + 1; i < rows; i++ {
+		if e := dist[i*cols]; e != 1 {
+			inv := galoisDivide(1, e, w)
+			for j := 0; j < cols; j++ {
+				dist[i*cols+j] = galoisMultiply(dist[i*cols+j], inv, w)
+			}
+		}
+	}
+	return dist
+}
+
+// reedSolR6Matrix returns the 2 x k coding matrix of a RAID-6 code. The
+// first row is all ones and the second holds the powers of 2.
+func reedSolR6Matrix(k, w int) []int {
+	matrix := make([]int, 2*k)
+	x := 1
+	for i := 0; i < k; i++ {
+		matrix[i] = 1
+		matrix[k+i] = x
+		x = galoisMultiply(x, 2, w)
+	}
+	return matrix
+}
+
+// cauchyOriginalMatrix returns the m x k Cauchy matrix with X = {0..m-1}
+// and Y = {m..m+k-1}.
+func cauchyOriginalMatrix(k, m, w int) []int {
+	if w < 31 && k+m > 1<<uint(w) {
+		return nil
+	}
+	matrix := make([]int, k*m)
+	for i := 0; i < m; i++ {
+		for j := 0; j < k; j++ {
+			matrix[i*k+j] = galoisDivide(1, i^(m+j), w)
+		}
+	}
+	return matrix
+}
+
+// cauchyXYMatrix returns the m x k Cauchy matrix with element i,j equal
+// to 1/(x[i]+y[j]). The elements of x and y have to be distinct.
+func cauchyXYMatrix(k, m, w int, x, y []int) []int {
+	matrix := make([]int, k*m)
+	for i := 0; i < m; i++ {
+		for j := 0; j < k; j++ {
+			matrix[i*k+j] = galoisDivide(1, x[i]^y[j], w)
+		}
+	}
+	return matrix
+}
+
+// cauchyImproveMatrix scales the columns of a Cauchy matrix so that its
+// first row is all ones, and then scales every other row so that its
+// bitmatrix has the fewest ones.
+func cauchyImproveMatrix(k, m, w int, matrix []int) {
+	for j := 0; j < k; j++ {
+		if matrix[j] != 1 {
+			inv := galoisDivide(1, matrix[j], w)
+			for i := 0; i < m; i++ {
+				matrix[i*k+j] = galoisMultiply(matrix[i*k+j], inv, w)
+			}
+		}
+	}
+
+	for i := 1; i < m; i++ {
+		row := matrix[i*k : (i+1)*k]
+		best := 0
+		for j := range row {
+			best += cauchyNOnes(row[j], w)
+		}
+		bestIndex := -1
+		for j := range row {
+			if row[j] == 1 {
+				continue
+			}
+			inv := galoisDivide(1, row[j], w)
+			ones := 0
+			for x := range row {
+				ones += cauchyNOnes(galoisMultiply(row[x], inv, w), w)
+			}
+			if ones < best {
+				best = ones
+				bestIndex = j
+			}
+		}
+		if bestIndex != -1 {
+			inv := galoisDivide(1, row[bestIndex], w)
+			for j := range row {
+				row[j] = galoisMultiply(row[j], inv, w)
+			}
+		}
+	}
+}
+
+// cauchyGoodMatrix returns the Cauchy matrix with the fewest ones in its
+// bitmatrix that jerasure knows of. For m = 2 these are tabulated.
+func cauchyGoodMatrix(k, m, w int) []int {
+	if m == 2 && w < len(cauchyBest) && k <= len(cauchyBest[w]) {
+		matrix := make([]int, k*m)
+		for i := 0; i < k; i++ {
+			matrix[i] = 1
+			matrix[i+k] = cauchyBest[w][i]
+		}
+		return matrix
+	}
+
+	matrix := cauchyOriginalMatrix(k, m, w)
+	if matrix == nil {
+		return nil
+	}
+	cauchyImproveMatrix(k, m, w, matrix)
+	return matrix
+}
+
+// cauchyNOnes returns the number of ones in the w x w bitmatrix of n.
+func cauchyNOnes(n, w int) int {
+	highbit := 1 << uint(w-1)
+	poly := galoisMultiply(highbit, 2, w)
+
+	no := 0
+	for i := 0; i < w; i++ {
+		if n&(1<<uint(i)) != 0 {
+			no++
+		}
+	}
+
+	// Every column of the bitmatrix is the previous one multiplied by 2.
+	// Multiplying by 2 shifts the column, which keeps its ones, unless
+	// the high bit is shifted out and the polynomial is added.
+	cno := no
+	for i := 1; i < w; i++ {
+		if n&highbit != 0 {
+			n ^= highbit
+			n <<= 1
+			n ^= poly
+			cno--
+			for j := 0; j < w; j++ {
+				if poly&(1<<uint(j)) == 0 {
+					continue
+				}
+				if n&(1<<uint(j)) != 0 {
+					cno++
+				} else {
+					cno--
+				}
+			}
+		} else {
+			n <<= 1
+		}
+		no += cno
+	}
+	return no
+}
+
+// matrixToBitmatrix expands an m x k matrix over GF(2^w) into an
+// mw x kw bitmatrix. Column x of the bitmatrix of an element e holds the
+// bits of e * 2^x.
+func matrixToBitmatrix(k, m, w int, matrix []int) []int {
+	bitmatrix := make([]int, k*m*w*w)
+	rowelts := k * w
+
+	for i := 0; i < m; i++ {
+		for j := 0; j < k; j++ {
+			elt := matrix[i*k+j]
+			index := i*rowelts*w + j*w
+			for x := 0; x < w; x++ {
+				for l := 0; l < w; l++ {
+					if elt&(1<<uint(l)) != 0 {
+						bitmatrix[index+x+l*rowelts] = 1
+					}
+				}
+				elt = galoisMultiply(elt, 2, w)
+			}
+		}
+	}
+	return bitmatrix
+}
+
+// newM2Bitmatrix returns a 2w x kw coding bitmatrix whose first w rows
+// compute the parity of the data.
+func newM2Bitmatrix(k, w int) []int {
+	matrix := make([]int, 2*k*w*w)
+	for i := 0; i < w; i++ {
+		for j := 0; j < k; j++ {
+			matrix[i*k*w+j*w+i] = 1
+		}
+	}
+	return matrix
+}
+
+// liberationBitmatrix returns the coding bitmatrix of a Liberation code.
+func liberationBitmatrix(k, w int) []int {
+	if k > w {
+		return nil
+	}
+	matrix := newM2Bitmatrix(k, w)
+
+	for j := 0; j < k; j++ {
+		index := k*w*w + j*w
+		for i := 0; i < w; i++ {
+			matrix[index+(j+i)%w] = 1
+			index += k * w
+		}
+		if j > 0 {
+			i := (j * ((w - 1) / 2)) % w
+			matrix[k*w*w+j*w+i*k*w+(i+j-1)%w] = 1
+		}
+	}
+	return matrix
+}
+
+// liber8tionRows holds the second coding row of the Liber8tion code. For
+// every data device it lists the bit that each of the eight rows picks,
+// followed by the row and bit of the one extra entry.
+var liber8tionRows = [8][10]int{
+	{0, 1, 2, 3, 4, 5, 6, 7, 0, 0},
+	{7, 3, 0, 2, 6, 1, 5, 4, 4, 7},
+	{6, 2, 4, 0, 7, 3, 1, 5, 1, 3},
+	{2, 5, 7, 6, 0, 3, 4, 1, 5, 4},
+	{5, 6, 1, 7, 2, 4, 3, 0, 2, 0},
+	{1, 2, 3, 4, 5, 6, 7, 0, 7, 2},
+	{3, 0, 6, 5, 1, 7, 4, 2, 6, 5},
+	{4, 7, 1, 5, 3, 2, 0, 6, 3, 1},
+}
+
+// liber8tionBitmatrix returns the coding bitmatrix of a Liber8tion code,
+// which has w = 8.
+func liber8tionBitmatrix(k int) []int {
+	w := 8
+	if k > w {
+		return nil
+	}
+	matrix := newM2Bitmatrix(k, w)
+
+	index := k * w * w
+	for j := 0; j < k; j++ {
+		row := liber8tionRows[j]
+		for i := 0; i < w; i++ {
+			matrix[index+i*k*w+j*w+row[i]] = 1
+		}
+		if j > 0 {
+			matrix[index+row[8]*k*w+j*w+row[9]] = 1
+		}
+	}
+	return matrix
+}
+
+// blaumRothBitmatrix returns the coding bitmatrix of a Blaum-Roth code.
+func blaumRothBitmatrix(k, w int) []int {
+	if k > w {
+		return nil
+	}
+	matrix := newM2Bitmatrix(k, w)
+
+	p := w + 1
+	for j := 0; j < k; j++ {
+		index := k*w*w + j*w
+		if j == 0 {
+			for l := 0; l < w; l++ {
+				matrix[index+l] = 1
+				index += k * w
+			}
+			continue
+		}
+		i := j
+		for l := 1; l <= w; l++ {
+			if l != p-i {
+				m := l + i
+				if m >= p {
+					m -= p
+				}
+				matrix[index+m-1] = 1
+			} else {
+				matrix[index+i-1] = 1
+				var m int
+				if i%2 == 0 {
+					m = i / 2
+				} else {
+					m = p/2 + 1 + i/2
+				}
+				matrix[index+m-1] = 1
+			}
+			index += k * w
+		}
+	}
+	return matrix
+}
+
+// invertMatrix returns the inverse of a square matrix over GF(2^w), or
+// nil if it is not invertible. The matrix is left unchanged.
+func invertMatrix(matrix []int, rows, w int) []int {
+	cols := rows
+	mat := append([]int(nil), matrix...)
+	inv := make([]int, rows*cols)
+	for i := 0; i < rows; i++ {
+		inv[i*cols+i] = 1
+	}
+
+	// Convert the matrix into upper triangular form
+	for i := 0; i < cols; i++ {
+		if mat[i*cols+i] == 0 {
+			j := i + 1
+			for j < rows && mat[j*cols+i] == 0 {
+				j++
+			}
+			if j == rows {
+				return nil
+			}
+			for x := 0; x < cols; x++ {
+				mat[i*cols+x], mat[j*cols+x] = mat[j*cols+x], mat[i*cols+x]
+				inv[i*cols+x], inv[j*cols+x] = inv[j*cols+x], inv[i*cols+x]
+			}
+		}
+
+		if e := mat[i*cols+i]; e != 1 {
+			e = galoisDivide(1, e, w)
+			for x := 0; x < cols; x++ {
+				mat[i*cols+x] = galoisMultiply(mat[i*cols+x], e, w)
+				inv[i*cols+x] = galoisMultiply(inv[i*cols+x], e, w)
+			}
+		}
+
+		for j := i + 1; j < rows; j++ {
+			if e := mat[j*cols+i]; e != 0 {
+				for x := 0; x < cols; x++ {
+					mat[j*cols+x] ^= galoisMultiply(e, mat[i*cols+x], w)
+					inv[j*cols+x] ^= galoisMultiply(e, inv[i*cols+x], w)
+				}
+			}
+		}
+	}
+
+	// Back substitute from the bottom row up
+	for i := rows - 1; i >= 0; i-- {
+		for j := 0; j < i; j++ {
+			if e := mat[j*cols+i]; e != 0 {
+				mat[j*cols+i] = 0
+				for x := 0; x < cols; x++ {
+					inv[j*cols+x] ^= galoisMultiply(e, inv[i*cols+x], w)
+				}
+			}
+		}
+	}
+	return inv
+}
+
+// invertBitmatrix returns the inverse of a square bitmatrix, or nil if it
+// is not invertible. The bitmatrix is left unchanged.
+func invertBitmatrix(bitmatrix []int, rows int) []int {
+	cols := rows
+	mat := append([]int(nil), bitmatrix...)
+	inv := make([]int, rows*cols)
+	for i := 0; i < rows; i++ {
+		inv[i*cols+i] = 1
+	}
+
+	for i := 0; i < cols; i++ {
+		if mat[i*cols+i] == 0 {
+			j := i + 1
+			for j < rows && mat[j*cols+i] == 0 {
+				j++
+			}
+			if j == rows {
+				return nil
+			}
+			for x := 0; x < cols; x++ {
+				mat[i*cols+x], mat[j*cols+x] = mat[j*cols+x], mat[i*cols+x]
+				inv[i*cols+x], inv[j*cols+x] = inv[j*cols+x], inv[i*cols+x]
+			}
+		}
+
+		for j := i + 1; j < rows; j++ {
+			if mat[j*cols+i] != 0 {
+				for x := 0; x < cols; x++ {
+					mat[j*cols+x] ^= mat[i*cols+x]
+					inv[j*cols+x] ^= inv[i*cols+x]
+				}
+			}
+		}
+	}
+
+	for i := rows - 1; i >= 0; i-- {
+		for j := 0; j < i; j++ {
+			if mat[j*cols+i] != 0 {
+				for x := 0; x < cols; x++ {
+					mat[j*cols+x] ^= mat[i*cols+x]
+					inv[j*cols+x] ^= inv[i*cols+x]
+				}
+			}
+		}
+	}
+	return inv
+}
+
+// isMDS reports whether every k blocks of a stripe encoded with an m x k
+// coding matrix can be decoded. That is when every k rows of the
+// distribution matrix, the identity on top of the coding matrix, are
+// invertible.
+func isMDS(k, m, w int, matrix []int) bool {
+	rows := make([]int, k*k)
+	return forEachSubset(k+m, k, func(ids []int) bool {
+		distributionRows(k, 1, matrix, ids, rows)
+		return invertMatrix(rows, k, w) != nil
+	})
+}
+
+// isMDSBitmatrix reports whether every k blocks of a stripe encoded with
+// an mw x kw coding bitmatrix can be decoded.
+func isMDSBitmatrix(k, m, w int, bitmatrix []int) bool {
+	rows := make([]int, k*w*k*w)
+	return forEachSubset(k+m, k, func(ids []int) bool {
+		distributionRows(k, w, bitmatrix, ids, rows)
+		return invertBitmatrix(rows, k*w) != nil
+	})
+}
+
+// distributionRows copies the rows of the blocks with the given ids from
+// the distribution bitmatrix of a coding bitmatrix into rows. Every block
+// has w rows; matrices over GF(2^w) are copied with w = 1.
+func distributionRows(k, w int, coding []int, ids []int, rows []int) {
+	cols := k * w
+	clear(rows)
+	for i, id := range ids {
+		dst := rows[i*w*cols : (i+1)*w*cols]
+		if id < k {
+			for x := 0; x < w; x++ {
+				dst[x*cols+id*w+x] = 1
+			}
+		} else {
+			copy(dst, coding[(id-k)*w*cols:])
+		}
+	}
+}
+
+// forEachSubset calls fn with every k element subset of 0..n-1, in
+// lexicographic order, until fn returns false. It reports whether fn
+// returned true for every subset.
+func forEachSubset(n, k int, fn func(ids []int) bool) bool {
+	ids := make([]int, k)
+	for i := range ids {
+		ids[i] = i
+	}
+	for {
+		if !fn(ids) {
+			return false
+		}
+		// Advance the last id that is not at its maximum
+		i := k - 1
+		for i >= 0 && ids[i] == n-k+i {
+			i--
+		}
+		if i < 0 {
+			return true
+		}
+		ids[i]++
+		for j := i + 1; j < k; j++ {
+			ids[j] = ids[j-1] + 1
+		}
+	}
+}
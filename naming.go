@@ -0,0 +1,114 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// DefaultNaming is the template that names the blocks of a stripe
+// <stripe>_k<i> and <stripe>_m<i>.
+const DefaultNaming = "{name}_{kind}{index}"
+
+// naming names the blocks of stripes by a template with the
+// placeholders described by WithNaming.
+type naming struct {
+	// parts holds the template split into literal text and placeholders,
+	// which keep their braces.
+	parts []string
+}
+
+// parseNaming parses a naming template. An empty template is
+// DefaultNaming.
+func parseNaming(template string) (*naming, error) {
+	if template == "" {
+		template = DefaultNaming
+	}
+	this := new(naming)
+	seen := make(map[string]bool)
+
+	for rest := template; rest != ""; {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			this.parts = append(this.parts, rest)
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unterminated placeholder in naming %q", ErrInvalidParams, template)
+		}
+		end += start + 1
+		placeholder := rest[start:end]
+		switch placeholder {
+		case "{name}", "{dir}", "{stripe}", "{kind}", "{index}", "{id}":
+		default:
+			return nil, fmt.Errorf("%w: unknown placeholder %s in naming %q", ErrInvalidParams, placeholder, template)
+		}
+		seen[placeholder] = true
+		if start > 0 {
+			this.parts = append(this.parts, rest[:start])
+		}
+		this.parts = append(this.parts, placeholder)
+		rest = rest[end:]
+	}
+
+	// Every block of a stripe has to get its own name
+	if !seen["{id}"] && !(seen["{kind}"] && seen["{index}"]) {
+		return nil, fmt.Errorf("%w: naming %q does not tell the blocks of a stripe apart", ErrInvalidParams, template)
+	}
+	return this, nil
+}
+
+// block returns the name of block id of a stripe. The coding ids are
+// above the data ids.
+func (this *naming) block(stripeName string, k, id int) string {
+	kind, index := "k", id
+	if id >= k {
+		kind, index = "m", id-k
+	}
+
+	var b strings.Builder
+	for _, part := range this.parts {
+		switch part {
+		case "{name}":
+			b.WriteString(stripeName)
+		case "{dir}":
+			b.WriteString(path.Dir(stripeName))
+		case "{stripe}":
+			b.WriteString(path.Base(stripeName))
+		case "{kind}":
+			b.WriteString(kind)
+		case "{index}":
+			b.WriteString(strconv.Itoa(index))
+		case "{id}":
+			b.WriteString(strconv.Itoa(id))
+		default:
+			b.WriteString(part)
+		}
+	}
+	return path.Clean(b.String())
+}
+
+// blocks returns the names of all k+m blocks of a stripe.
+func (this *naming) blocks(stripeName string, k, m int) []string {
+	names := make([]string, k+m)
+	for id := range names {
+		names[id] = this.block(stripeName, k, id)
+	}
+	return names
+}
@@ -0,0 +1,101 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestNaming(t *testing.T) {
+	tests := []struct {
+		template string
+		stripe   string
+		names    []string
+	}{
+		{"", "testfiles/stripe", []string{"testfiles/stripe_k0", "testfiles/stripe_k1", "testfiles/stripe_m0"}},
+		{DefaultNaming, "stripe", []string{"stripe_k0", "stripe_k1", "stripe_m0"}},
+		{"{dir}/{stripe}/{kind}{index}.shard", "a/b", []string{"a/b/k0.shard", "a/b/k1.shard", "a/b/m0.shard"}},
+		{"{dir}/{stripe}/{kind}{index}.shard", "b", []string{"b/k0.shard", "b/k1.shard", "b/m0.shard"}},
+		{"disk{id}/{stripe}.{kind}{index}", "a/b", []string{"disk0/b.k0", "disk1/b.k1", "disk2/b.m0"}},
+	}
+	for _, test := range tests {
+		naming, err := parseNaming(test.template)
+		if err != nil {
+			t.Fatalf("%q: %v", test.template, err)
+		}
+		if names := naming.blocks(test.stripe, 2, 1); !reflect.DeepEqual(names, test.names) {
+			t.Errorf("%q: expected names %v, got %v", test.template, test.names, names)
+		}
+	}
+
+	for _, template := range []string{"{name}_{kind}", "{name}_{index}", "{name}_{id", "{name}_{ext}{id}"} {
+		if _, err := parseNaming(template); !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("%q: expected ErrInvalidParams, got %v", template, err)
+		}
+	}
+}
+
+// TestNamingStripe encodes a stripe with a naming template and decodes
+// it with the template recorded in its manifest.
+func TestNamingStripe(t *testing.T) {
+	k, m := 3, 2
+	code, err := NewReedSolVanCode(k, m, 8, 0, 1536)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer code.Close()
+
+	const template = "{dir}/{stripe}/{kind}{index}.shard"
+	for name, store := range map[string]ShardStore{"dir": NewDirStore(t.TempDir()), "mem": NewMemStore()} {
+		blocks := make([][]byte, k)
+		for i := range blocks {
+			blocks[i] = make([]byte, 2000)
+			rand.Read(blocks[i])
+			writeTestShard(t, store, fmt.Sprintf("stripes/a/k%d.shard", i), blocks[i])
+		}
+
+		if err = Encode("stripes/a", code, WithStore(store), WithNaming(template)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		manifest, err := ReadManifest("stripes/a", WithStore(store))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if manifest.Naming != template {
+			t.Errorf("%s: expected the naming to be recorded, got %q", name, manifest.Naming)
+		}
+		if names, _ := store.List("stripes/a/m"); !reflect.DeepEqual(names, []string{"stripes/a/m0.shard", "stripes/a/m1.shard"}) {
+			t.Errorf("%s: unexpected coding blocks %v", name, names)
+		}
+
+		store.Delete("stripes/a/k0.shard")
+		store.Delete("stripes/a/m1.shard")
+		if err = Decode("stripes/a", code, WithStore(store)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		decoded, err := readTestShard(store, "stripes/a/k0.shard")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded, blocks[0]) {
+			t.Errorf("%s: decoded data block differs from the original", name)
+		}
+	}
+}
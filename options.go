@@ -0,0 +1,215 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"fmt"
+	"log"
+)
+
+// Option configures how Encode and Decode process a stripe.
+type Option func(*options)
+
+// options holds the settings that can be changed with an Option.
+type options struct {
+	// hash is the name of the hash used for block checksums.
+	hash string
+	// workers is the number of buffers that are coded concurrently.
+	workers int
+	// inflight is the number of rounds of k+m buffers that are held in
+	// memory at once. Zero means twice the number of workers.
+	inflight int
+	// sync selects when written blocks are flushed to stable storage.
+	sync SyncMode
+	// store holds the blocks and manifests of the stripes.
+	store ShardStore
+	// naming is the template that names the blocks of the stripes.
+	// Empty means the template recorded in the manifest of a stripe
+	// that is decoded, or else DefaultNaming.
+	naming string
+	// roots holds the roots that the blocks are placed on, if any.
+	roots []string
+	// logger reports the blocks that are missing, lost or corrupt, if
+	// it is set.
+	logger *log.Logger
+}
+
+// newOptions returns the default options with opts applied.
+func newOptions(opts []Option) *options {
+	o := &options{
+		hash:    CRC32C,
+		workers: 1,
+		store:   NewDirStore(""),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.inflight == 0 {
+		o.inflight = 2 * o.workers
+	}
+	return o
+}
+
+// WithHash selects the hash that Encode uses for the block checksums in
+// the manifest, by a name registered with RegisterHash. The default is
+// CRC32C.
+func WithHash(name string) Option {
+	return func(o *options) {
+		o.hash = name
+	}
+}
+
+// WithWorkers sets the number of goroutines that encode or decode
+// buffers concurrently. The output is the same for any number of
+// workers.
+func WithWorkers(n int) Option {
+	return func(o *options) {
+		o.workers = max(n, 1)
+	}
+}
+
+// WithInflight sets the number of rounds of k+m buffers that Encode and
+// Decode hold in memory at once. The n rounds are allocated up front,
+// which bounds their memory use to n*(k+m)*bufferSize bytes, unless the
+// stripe has fewer rounds. It is raised to the number of workers if it
+// is lower. The default is twice the number of workers.
+func WithInflight(n int) Option {
+	return func(o *options) {
+		o.inflight = max(n, 1)
+	}
+}
+
+// WithSync selects when the blocks and manifest written by Encode and
+// Decode are flushed to stable storage. The default is SyncNone.
+func WithSync(mode SyncMode) Option {
+	return func(o *options) {
+		o.sync = mode
+	}
+}
+
+// WithStore selects the ShardStore that holds the blocks and manifests
+// of the stripes. The default stores them as files relative to the
+// working directory.
+func WithStore(store ShardStore) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+// WithNaming selects the template that names the blocks of a stripe in
+// its ShardStore, such as "{dir}/{stripe}/{kind}{index}.shard" or
+// "disk{id}/{stripe}.{kind}{index}". The placeholders are
+//
+//	{name}   the stripe name
+//	{dir}    the directory of the stripe name
+//	{stripe} the stripe name without its directory
+//	{kind}   k for a data block and m for a coding block
+//	{index}  the index of the block among the blocks of its kind
+//	{id}     the id of the block in the stripe, data blocks first
+//
+// The template has to hold {id}, or both {kind} and {index}. Encode
+// records it in the manifest, from which Decode picks it up when no
+// template is selected. The default is DefaultNaming.
+func WithNaming(template string) Option {
+	return func(o *options) {
+		o.naming = template
+	}
+}
+
+// WithRoots places the blocks of a stripe on distinct roots, such as
+// the mount points of separate discs, so that losing a root only erases
+// a single block of the stripe. The blocks are named by joining their
+// root and their name, as given by Placement.
+//
+// Encode records the placement in the manifest, a copy of which it
+// writes to every root of the stripe. Decode erases all blocks on roots
+// that lost their copy of the manifest, and writes the regenerated
+// blocks of those roots to spare roots, which hold no block of the
+// stripe. Once no spare root is left, the blocks are written back to
+// their own roots, which have to have been replaced.
+func WithRoots(roots ...string) Option {
+	return func(o *options) {
+		o.roots = roots
+	}
+}
+
+// WithLogger reports the blocks that Decode, VerifyStripe and
+// DecodeJerasure find missing, lost or corrupt to logger. Nothing is
+// logged by default.
+func WithLogger(logger *log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// logf logs a message to the logger selected with WithLogger, if any.
+func (this *options) logf(format string, args ...interface{}) {
+	if this.logger != nil {
+		this.logger.Printf(format, args...)
+	}
+}
+
+// Execution selects how a bitmatrix code computes the coding blocks.
+// Every execution produces the same blocks, but they differ in the
+// number of XORs and copies needed, which EncodeStats and DecodeStats
+// report.
+//
+// The pure Go codes always work on the bitmatrix, like NoSchedule.
+type Execution int
+
+const (
+	// SmartSchedule computes the blocks with a schedule of operations
+	// that reuses earlier results, created by
+	// jerasure_smart_bitmatrix_to_schedule. It is the default.
+	SmartSchedule Execution = iota
+	// DumbSchedule computes the blocks with a schedule of one operation
+	// for every one in the bitmatrix, created by
+	// jerasure_dumb_bitmatrix_to_schedule.
+	DumbSchedule
+	// NoSchedule computes the blocks from the bitmatrix itself, with
+	// jerasure_bitmatrix_encode and jerasure_bitmatrix_decode.
+	NoSchedule
+)
+
+// CodeOption configures a bitmatrix code when it is created.
+type CodeOption func(*codeOptions)
+
+// codeOptions holds the settings that can be changed with a CodeOption.
+type codeOptions struct {
+	// execution selects how the coding blocks are computed.
+	execution Execution
+}
+
+// newCodeOptions returns the default code options with opts applied.
+func newCodeOptions(opts []CodeOption) (*codeOptions, error) {
+	o := &codeOptions{
+		execution: SmartSchedule,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.execution < SmartSchedule || o.execution > NoSchedule {
+		return nil, fmt.Errorf("%w: unknown execution %d", ErrInvalidParams, o.execution)
+	}
+	return o, nil
+}
+
+// WithExecution selects how a bitmatrix code computes the coding blocks.
+// The default is SmartSchedule.
+func WithExecution(execution Execution) CodeOption {
+	return func(o *codeOptions) {
+		o.execution = execution
+	}
+}
@@ -0,0 +1,126 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import "sync"
+
+// round holds the data and coding buffers of one readin of a stripe as
+// it moves through a pipeline.
+type round struct {
+	// index is the position of the round in the stripe.
+	index  int
+	data   [][]byte
+	coding [][]byte
+	err    error
+}
+
+// pipeline reads, codes and writes the rounds of a stripe. Rounds are
+// read and written in order by a single goroutine each, while up to
+// workers rounds are coded concurrently in between.
+type pipeline struct {
+	workers int
+	// inflight bounds the number of rounds, and so buffers, that are
+	// allocated at once.
+	inflight int
+	k, m     int
+	size     int64
+	// read fills the data, and for decoding the coding, buffers of a
+	// round, code encodes or decodes it and write stores the result.
+	read  func(*round) error
+	code  func(*round) error
+	write func(*round) error
+}
+
+// run passes rounds 0 to rounds-1 through the pipeline and returns the
+// first error that occurred.
+func (this *pipeline) run(rounds int) error {
+	workers := max(this.workers, 1)
+	inflight := max(this.inflight, workers)
+	// A stripe of fewer rounds than may be in flight does not need
+	// buffers for the rest
+	inflight = max(min(inflight, rounds), 1)
+
+	free := make(chan *round, inflight)
+	for i := 0; i < inflight; i++ {
+		free <- &round{
+			data:   allocateBuffers(this.k, this.size),
+			coding: allocateBuffers(this.m, this.size),
+		}
+	}
+
+	// The channels can hold every round in flight, so sending on them
+	// never blocks.
+	work := make(chan *round, inflight)
+	done := make(chan *round, inflight)
+	quit := make(chan struct{})
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(work)
+		for i := 0; i < rounds; i++ {
+			var r *round
+			select {
+			case r = <-free:
+			case <-quit:
+				return
+			}
+			r.index = i
+			err := this.read(r)
+			r.err = err
+			work <- r
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range work {
+				if r.err == nil {
+					r.err = this.code(r)
+				}
+				done <- r
+			}
+		}()
+	}
+
+	// Write the rounds in order as they complete
+	pending := make(map[int]*round)
+	for next := 0; next < rounds; {
+		r := <-done
+		pending[r.index] = r
+
+		for r = pending[next]; r != nil; r = pending[next] {
+			delete(pending, next)
+			if r.err == nil {
+				r.err = this.write(r)
+			}
+			if r.err != nil {
+				close(quit)
+				return r.err
+			}
+			free <- r
+			next++
+		}
+	}
+	return nil
+}
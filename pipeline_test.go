@@ -0,0 +1,117 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPipeline(t *testing.T) {
+	var mu sync.Mutex
+	outstanding, maxOutstanding := 0, 0
+	next := 0
+
+	p := &pipeline{
+		workers:  4,
+		inflight: 6,
+		k:        2,
+		m:        1,
+		size:     8,
+	}
+	p.read = func(r *round) error {
+		mu.Lock()
+		defer mu.Unlock()
+		outstanding++
+		maxOutstanding = max(maxOutstanding, outstanding)
+		r.data[0][0] = byte(r.index)
+		return nil
+	}
+	p.code = func(r *round) error {
+		// Finish the rounds out of order
+		time.Sleep(time.Duration(r.index%3) * time.Millisecond)
+		r.coding[0][0] = r.data[0][0]
+		return nil
+	}
+	p.write = func(r *round) error {
+		mu.Lock()
+		defer mu.Unlock()
+		outstanding--
+		if r.index != next || int(r.coding[0][0]) != r.index {
+			t.Errorf("round %d written in position %d", r.index, next)
+		}
+		next++
+		return nil
+	}
+
+	if err := p.run(50); err != nil {
+		t.Fatal(err)
+	}
+	if next != 50 {
+		t.Errorf("wrote %d rounds, expected 50", next)
+	}
+	if maxOutstanding > p.inflight {
+		t.Errorf("%d rounds were in flight, expected at most %d", maxOutstanding, p.inflight)
+	}
+}
+
+func TestPipelineFewRounds(t *testing.T) {
+	var mu sync.Mutex
+	allocated := make(map[*round]bool)
+
+	p := &pipeline{workers: 4, inflight: 8, k: 2, m: 1, size: 8}
+	p.read = func(r *round) error {
+		mu.Lock()
+		defer mu.Unlock()
+		allocated[r] = true
+		return nil
+	}
+	p.code = func(r *round) error { return nil }
+	p.write = func(r *round) error { return nil }
+
+	if err := p.run(2); err != nil {
+		t.Fatal(err)
+	}
+	if len(allocated) > 2 {
+		t.Errorf("%d rounds were allocated for a stripe of 2", len(allocated))
+	}
+}
+
+func TestPipelineError(t *testing.T) {
+	failed := errors.New("failed")
+	written := 0
+
+	p := &pipeline{workers: 2, k: 1, m: 1, size: 8}
+	p.read = func(r *round) error { return nil }
+	p.code = func(r *round) error {
+		if r.index == 7 {
+			return failed
+		}
+		return nil
+	}
+	p.write = func(r *round) error {
+		written++
+		return nil
+	}
+
+	if err := p.run(20); err != failed {
+		t.Errorf("expected the code error, got %v", err)
+	}
+	if written != 7 {
+		t.Errorf("wrote %d rounds before the error, expected 7", written)
+	}
+}
@@ -0,0 +1,134 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"path"
+)
+
+// Placement returns the root of every block of a stripe with k data and
+// m coding blocks, data blocks first. Every block is placed on a
+// distinct root, so that losing a root only erases a single block of
+// the stripe, which means that there have to be at least k+m roots.
+//
+// The placement only depends on the stripe name and the roots. The
+// blocks of a stripe take consecutive roots, starting at a root picked
+// by the stripe name, so that the stripes are spread over all roots.
+func Placement(stripeName string, k, m int, roots []string) ([]string, error) {
+	if len(roots) < k+m {
+		return nil, fmt.Errorf("%w: %d roots cannot hold %d blocks on distinct roots", ErrInvalidParams, len(roots), k+m)
+	}
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		if seen[root] {
+			return nil, fmt.Errorf("%w: root %q is listed twice", ErrInvalidParams, root)
+		}
+		seen[root] = true
+	}
+
+	start := int(crc32.ChecksumIEEE([]byte(stripeName)) % uint32(len(roots)))
+	placement := make([]string, k+m)
+	for id := range placement {
+		placement[id] = roots[(start+id)%len(roots)]
+	}
+	return placement, nil
+}
+
+// placeBlocks returns the names of the blocks of a stripe on the roots of
+// a placement.
+func placeBlocks(names, placement []string) []string {
+	placed := make([]string, len(names))
+	for id, name := range names {
+		placed[id] = path.Join(placement[id], name)
+	}
+	return placed
+}
+
+// manifestNames returns the names of the manifests of a stripe. A copy
+// of the manifest is kept on every root of a placed stripe, so that it
+// survives the loss of any root.
+func manifestNames(stripeName string, placement []string) []string {
+	if placement == nil {
+		return []string{manifestName(stripeName)}
+	}
+	names := make([]string, len(placement))
+	for id, root := range placement {
+		names[id] = manifestName(path.Join(root, stripeName))
+	}
+	return names
+}
+
+// readStripeManifest reads the manifest of a stripe. If the stripe was
+// placed on roots, the manifest is read from the first root that holds
+// a copy, and the roots that hold a copy are returned as available. A
+// root without a copy of the manifest has lost all blocks of the stripe
+// as well.
+func readStripeManifest(store ShardStore, stripeName string, roots []string) (manifest *Manifest, available map[string]bool, err error) {
+	if roots == nil {
+		manifest, err = readManifest(store, stripeName)
+		return manifest, nil, err
+	}
+
+	available = make(map[string]bool)
+	err = ErrNoManifest
+	for _, root := range roots {
+		other, rerr := readManifest(store, path.Join(root, stripeName))
+		if rerr != nil {
+			if manifest == nil && !errors.Is(rerr, ErrNoManifest) {
+				err = rerr
+			}
+			continue
+		}
+		available[root] = true
+		if manifest == nil {
+			manifest, err = other, nil
+		}
+	}
+	return manifest, available, err
+}
+
+// relocate moves the erased blocks on lost roots to spare roots, which
+// hold no other block of the stripe, and renames them from their
+// unplaced names. Blocks for which no spare root is left stay on their
+// own root, which has to have been replaced for them to be written. It
+// reports whether any erased block was on a lost root, in which case the
+// manifest has to be written to the roots of the stripe again.
+func relocate(placement, names, unplaced []string, erased []int, lost []bool, roots []string) (rewrite bool) {
+	used := make(map[string]bool)
+	for _, root := range placement {
+		used[root] = true
+	}
+	var spares []string
+	for _, root := range roots {
+		if !used[root] {
+			spares = append(spares, root)
+		}
+	}
+
+	for _, id := range erased {
+		if !lost[id] {
+			continue
+		}
+		rewrite = true
+		if len(spares) > 0 {
+			placement[id], spares = spares[0], spares[1:]
+			names[id] = path.Join(placement[id], unplaced[id])
+		}
+	}
+	return rewrite
+}
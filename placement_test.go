@@ -0,0 +1,187 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPlacement(t *testing.T) {
+	roots := []string{"disk0", "disk1", "disk2", "disk3", "disk4", "disk5"}
+
+	starts := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		stripeName := fmt.Sprintf("stripe%d", i)
+		placement, err := Placement(stripeName, 3, 2, roots)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, _ := Placement(stripeName, 3, 2, roots)
+		if !reflect.DeepEqual(placement, again) {
+			t.Fatalf("%s: placement %v changed to %v", stripeName, placement, again)
+		}
+		seen := make(map[string]bool)
+		for _, root := range placement {
+			if seen[root] {
+				t.Fatalf("%s: root %s holds two blocks in %v", stripeName, root, placement)
+			}
+			seen[root] = true
+		}
+		starts[placement[0]] = true
+	}
+	if len(starts) < 2 {
+		t.Errorf("expected stripes to start on different roots, all started on %v", starts)
+	}
+
+	if _, err := Placement("stripe", 3, 2, roots[:4]); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("too few roots: expected ErrInvalidParams, got %v", err)
+	}
+	if _, err := Placement("stripe", 3, 2, append(roots[:5:5], "disk0")); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("duplicate roots: expected ErrInvalidParams, got %v", err)
+	}
+}
+
+// TestPlacedStripe loses a root of a placed stripe and ensures that its
+// block is regenerated on a spare root.
+func TestPlacedStripe(t *testing.T) {
+	k, m := 3, 2
+	code, err := NewCauchyGoodCode(k, m, 8, 8, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer code.Close()
+
+	dir := t.TempDir()
+	store := NewDirStore(dir)
+	roots := []string{"disk0", "disk1", "disk2", "disk3", "disk4", "disk5", "disk6"}
+	placement, err := Placement("stripe", k, m, roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := make([][]byte, k)
+	for i := range blocks {
+		blocks[i] = make([]byte, 5000)
+		rand.Read(blocks[i])
+		writeTestShard(t, store, path.Join(placement[i], fmt.Sprintf("stripe_k%d", i)), blocks[i])
+	}
+	if err = Encode("stripe", code, WithStore(store), WithRoots(roots...)); err != nil {
+		t.Fatal(err)
+	}
+	for id, root := range placement {
+		if _, err = store.Stat(path.Join(root, "stripe_meta")); err != nil {
+			t.Errorf("expected a copy of the manifest on the root of block %d: %v", id, err)
+		}
+	}
+	if _, err = store.Stat(path.Join(placement[k], "stripe_m0")); err != nil {
+		t.Errorf("expected the first coding block on its root: %v", err)
+	}
+
+	// Lose the roots of a data and a coding block
+	os.RemoveAll(filepath.Join(dir, placement[1]))
+	os.RemoveAll(filepath.Join(dir, placement[k+1]))
+	if err = DecodeStripe("stripe", WithStore(store), WithRoots(roots...)); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := ReadManifest("stripe", WithStore(store), WithRoots(roots...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved := manifest.Placement
+	if moved[1] == placement[1] || moved[k+1] == placement[k+1] {
+		t.Fatalf("expected the blocks of the lost roots to be moved, got placement %v", moved)
+	}
+	decoded, err := readTestShard(store, path.Join(moved[1], "stripe_k1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, blocks[1]) {
+		t.Error("decoded data block differs from the original")
+	}
+	if _, err = store.Stat(path.Join(moved[k+1], "stripe_m1")); err != nil {
+		t.Errorf("expected the coding block on its spare root: %v", err)
+	}
+}
+
+// TestPlacedStripeNoSpare loses a root of a stripe placed on exactly k+m
+// roots, and ensures that its block is regenerated on the replaced root,
+// or that Decode fails if the root cannot be written.
+func TestPlacedStripeNoSpare(t *testing.T) {
+	k, m := 3, 2
+	code, err := NewCauchyGoodCode(k, m, 8, 8, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer code.Close()
+
+	dir := t.TempDir()
+	store := NewDirStore(dir)
+	roots := []string{"disk0", "disk1", "disk2", "disk3", "disk4"}
+	placement, err := Placement("stripe", k, m, roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := make([][]byte, k)
+	for i := range blocks {
+		blocks[i] = make([]byte, 5000)
+		rand.Read(blocks[i])
+		writeTestShard(t, store, path.Join(placement[i], fmt.Sprintf("stripe_k%d", i)), blocks[i])
+	}
+	if err = Encode("stripe", code, WithStore(store), WithRoots(roots...)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A root that cannot be written fails the decode
+	os.RemoveAll(filepath.Join(dir, placement[1]))
+	if err = os.WriteFile(filepath.Join(dir, placement[1]), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = DecodeStripe("stripe", WithStore(store), WithRoots(roots...)); err == nil {
+		t.Error("expected decoding onto an unwritable root to fail")
+	}
+
+	// A replaced root gets its block and manifest back
+	os.Remove(filepath.Join(dir, placement[1]))
+	if err = DecodeStripe("stripe", WithStore(store), WithRoots(roots...)); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := readTestShard(store, path.Join(placement[1], "stripe_k1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, blocks[1]) {
+		t.Error("decoded data block differs from the original")
+	}
+	if _, err = store.Stat(path.Join(placement[1], "stripe_meta")); err != nil {
+		t.Errorf("expected the manifest on the replaced root: %v", err)
+	}
+	damaged, err := VerifyStripe("stripe", WithStore(store), WithRoots(roots...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(damaged) != 0 {
+		t.Errorf("expected no damaged blocks, got %v", damaged)
+	}
+}
@@ -0,0 +1,346 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"github.com/jsgilmore/goerasure/gf"
+)
+
+// goMatrixCode is a code that uses a coding matrix, encoded and decoded
+// in Go the way jerasure_matrix_encode and jerasure_matrix_decode do.
+type goMatrixCode struct {
+	code
+	matrix []int
+}
+
+// Encode encodes a matrix code, given a data block and writes the
+// output into the coding block
+func (this *goMatrixCode) Encode(data, coding [][]byte) error {
+	_, err := this.record(this.encodeStats(data, coding))
+	return err
+}
+
+// encodeStats encodes the blocks like Encode and returns the work that
+// was done.
+func (this *goMatrixCode) encodeStats(data, coding [][]byte) (stats Stats, err error) {
+	if this.matrix == nil {
+		return stats, ErrClosed
+	}
+	if err = this.checkBlocks(data, coding); err != nil {
+		return stats, err
+	}
+	for i := range coding {
+		this.dotprod(this.matrix[i*this.k:(i+1)*this.k], data, coding[i], &stats)
+	}
+	return stats, nil
+}
+
+// Decode decodes a matrix code, given (partially filled) data and
+// coding blocks and fills in the missing slices in the blocks.
+func (this *goMatrixCode) Decode(data, coding [][]byte, erasures []int) error {
+	_, err := this.record(this.decodeStats(data, coding, erasures))
+	return err
+}
+
+// decodeStats decodes the blocks like Decode and returns the work that
+// was done.
+func (this *goMatrixCode) decodeStats(data, coding [][]byte, erasures []int) (stats Stats, err error) {
+	if this.matrix == nil {
+		return stats, ErrClosed
+	}
+	if err = this.checkBlocks(data, coding); err != nil {
+		return stats, err
+	}
+	if err = this.checkErasures(erasures); err != nil {
+		return stats, err
+	}
+	k := this.k
+	erased := erasedBlocks(k, this.m, erasures)
+
+	// Decode the data blocks from the first k blocks that survived, with
+	// the inverse of their rows of the distribution matrix
+	if ids := decodingIDs(k, erased); ids != nil {
+		rows := make([]int, k*k)
+		src := make([][]byte, k)
+		for i, id := range ids {
+			if id < k {
+				rows[i*k+id] = 1
+				src[i] = data[id]
+			} else {
+				copy(rows[i*k:(i+1)*k], this.matrix[(id-k)*k:])
+				src[i] = coding[id-k]
+			}
+		}
+		inv := invertMatrix(rows, k, this.w)
+		if inv == nil {
+			return stats, ErrDecodeFailed
+		}
+		for i := 0; i < k; i++ {
+			if erased[i] {
+				this.dotprod(inv[i*k:(i+1)*k], src, data[i], &stats)
+			}
+		}
+	}
+
+	// Re-encode the erased coding blocks
+	for i := range coding {
+		if erased[k+i] {
+			this.dotprod(this.matrix[i*k:(i+1)*k], data, coding[i], &stats)
+		}
+	}
+	return stats, nil
+}
+
+// dotprod sets dst to the dot product of a matrix row and the src blocks
+// and adds the work to stats, the way jerasure_matrix_dotprod counts it.
+func (this *goMatrixCode) dotprod(row []int, src [][]byte, dst []byte, stats *Stats) {
+	started := false
+
+	// First copy or add the blocks that do not need to be multiplied
+	for i, e := range row {
+		if e != 1 {
+			continue
+		}
+		if !started {
+			copy(dst, src[i])
+			stats.CopyBytes += int64(len(dst))
+			started = true
+		} else {
+			gf.XorRegion(dst, src[i])
+			stats.XORBytes += int64(len(dst))
+		}
+	}
+
+	f := field(this.w)
+	for i, e := range row {
+		if e == 0 || e == 1 {
+			continue
+		}
+		f.MulRegion(dst, src[i], uint32(e), started)
+		stats.GFBytes += int64(len(dst))
+		started = true
+	}
+
+	if !started {
+		zero(dst)
+	}
+}
+
+// Close releases the coding matrix. The code cannot be used afterwards.
+func (this *goMatrixCode) Close() error {
+	this.matrix = nil
+	return nil
+}
+
+// codingMatrix returns a copy of the m x k coding matrix, or nil if the
+// code was closed.
+func (this *goMatrixCode) codingMatrix() []int {
+	if this.matrix == nil {
+		return nil
+	}
+	return append([]int(nil), this.matrix...)
+}
+
+// reedSolR6Encode encodes a RAID-6 code the way reed_sol_r6_encode does.
+// The second coding block is evaluated with Horner's rule, so that every
+// data block is only multiplied by two.
+func reedSolR6Encode(w int, data, coding [][]byte) {
+	k := len(data)
+
+	copy(coding[0], data[0])
+	for i := 1; i < k; i++ {
+		gf.XorRegion(coding[0], data[i])
+	}
+
+	f := field(w)
+	copy(coding[1], data[k-1])
+	for i := k - 2; i >= 0; i-- {
+		f.MulRegion(coding[1], coding[1], 2, false)
+		gf.XorRegion(coding[1], data[i])
+	}
+}
+
+// goBitmatrixCode is a code that uses a coding bitmatrix, encoded and
+// decoded in Go the way jerasure_bitmatrix_encode and
+// jerasure_bitmatrix_decode do. Schedules only reorder the XORs of the
+// bitmatrix, so the coding blocks match those of the C schedules.
+type goBitmatrixCode struct {
+	code
+	bitmatrix []int
+}
+
+// Encode encodes a bit matrix code, given a data block and writes the
+// output into the coding block
+func (this *goBitmatrixCode) Encode(data, coding [][]byte) error {
+	_, err := this.record(this.encodeStats(data, coding))
+	return err
+}
+
+// encodeStats encodes the blocks like Encode and returns the work that
+// was done.
+func (this *goBitmatrixCode) encodeStats(data, coding [][]byte) (stats Stats, err error) {
+	if this.bitmatrix == nil {
+		return stats, ErrClosed
+	}
+	if err = this.checkBlocks(data, coding); err != nil {
+		return stats, err
+	}
+	n := this.k * this.w * this.w
+	for i := range coding {
+		this.dotprod(this.bitmatrix[i*n:(i+1)*n], data, coding[i], &stats)
+	}
+	return stats, nil
+}
+
+// Decode decodes a bit matrix code, given (partially filled) data and
+// coding blocks and fills in the missing slices in the blocks.
+func (this *goBitmatrixCode) Decode(data, coding [][]byte, erasures []int) error {
+	_, err := this.record(this.decodeStats(data, coding, erasures))
+	return err
+}
+
+// decodeStats decodes the blocks like Decode and returns the work that
+// was done.
+func (this *goBitmatrixCode) decodeStats(data, coding [][]byte, erasures []int) (stats Stats, err error) {
+	if this.bitmatrix == nil {
+		return stats, ErrClosed
+	}
+	if err = this.checkBlocks(data, coding); err != nil {
+		return stats, err
+	}
+	if err = this.checkErasures(erasures); err != nil {
+		return stats, err
+	}
+	k, w := this.k, this.w
+	n := k * w * w
+	erased := erasedBlocks(k, this.m, erasures)
+
+	if ids := decodingIDs(k, erased); ids != nil {
+		rows := make([]int, n*k)
+		src := make([][]byte, k)
+		for i, id := range ids {
+			if id < k {
+				for j := 0; j < w; j++ {
+					rows[i*n+id*w+j*(k*w+1)] = 1
+				}
+				src[i] = data[id]
+			} else {
+				copy(rows[i*n:(i+1)*n], this.bitmatrix[(id-k)*n:])
+				src[i] = coding[id-k]
+			}
+		}
+		inv := invertBitmatrix(rows, k*w)
+		if inv == nil {
+			return stats, ErrDecodeFailed
+		}
+		for i := 0; i < k; i++ {
+			if erased[i] {
+				this.dotprod(inv[i*n:(i+1)*n], src, data[i], &stats)
+			}
+		}
+	}
+
+	for i := range coding {
+		if erased[k+i] {
+			this.dotprod(this.bitmatrix[i*n:(i+1)*n], data, coding[i], &stats)
+		}
+	}
+	return stats, nil
+}
+
+// dotprod sets dst to the product of w rows of a bitmatrix and the src
+// blocks. The blocks are processed in groups of w packets, where row j
+// of the bitmatrix produces packet j of the group. The work is added to
+// stats, the way jerasure_bitmatrix_dotprod counts it.
+func (this *goBitmatrixCode) dotprod(rows []int, src [][]byte, dst []byte, stats *Stats) {
+	k, w, p := this.k, this.w, this.packetSize
+
+	for offset := 0; offset < len(dst); offset += w * p {
+		index := 0
+		for j := 0; j < w; j++ {
+			packet := dst[offset+j*p : offset+(j+1)*p]
+			started := false
+			for x := 0; x < k; x++ {
+				for y := 0; y < w; y++ {
+					if rows[index] != 0 {
+						in := src[x][offset+y*p : offset+(y+1)*p]
+						if !started {
+							copy(packet, in)
+							stats.CopyBytes += int64(p)
+							started = true
+						} else {
+							gf.XorRegion(packet, in)
+							stats.XORBytes += int64(p)
+						}
+					}
+					index++
+				}
+			}
+			if !started {
+				zero(packet)
+			}
+		}
+	}
+}
+
+// Close releases the coding bitmatrix. The code cannot be used
+// afterwards.
+func (this *goBitmatrixCode) Close() error {
+	this.bitmatrix = nil
+	return nil
+}
+
+// codingBitmatrix returns a copy of the mw x kw coding bitmatrix, or nil
+// if the code was closed.
+func (this *goBitmatrixCode) codingBitmatrix() []int {
+	if this.bitmatrix == nil {
+		return nil
+	}
+	return append([]int(nil), this.bitmatrix...)
+}
+
+// erasedBlocks converts a -1 terminated erasure list into a flag for
+// every block in the stripe.
+func erasedBlocks(k, m int, erasures []int) []bool {
+	erased := make([]bool, k+m)
+	for _, id := range erasures {
+		if id == -1 {
+			break
+		}
+		erased[id] = true
+	}
+	return erased
+}
+
+// decodingIDs returns the ids of the first k blocks that were not erased,
+// from which the data blocks are decoded, or nil if no data block was
+// erased.
+func decodingIDs(k int, erased []bool) []int {
+	dataErased := false
+	for _, e := range erased[:k] {
+		dataErased = dataErased || e
+	}
+	if !dataErased {
+		return nil
+	}
+
+	ids := make([]int, 0, k)
+	for id, e := range erased {
+		if !e && len(ids) < k {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,154 @@
+//go:build linux && cgo && !purego
+// +build linux,cgo,!purego
+
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// newGoCode returns the Go implementation of a code, for comparison with
+// the Jerasure one.
+func newGoCode(technique string, k, m, w, packetSize int, bufferSize int64) interface {
+	Encode(data, coding [][]byte) error
+	Decode(data, coding [][]byte, erasures []int) error
+} {
+	c := newCode(k, m, w, packetSize, bufferSize)
+	switch technique {
+	case ReedSolVan:
+		return &goMatrixCode{c, reedSolVandermondeMatrix(k, m, w)}
+	case ReedSolR6:
+		return goReedSolR6Code{&goMatrixCode{c, reedSolR6Matrix(k, w)}}
+	case CauchyOrig:
+		return &goBitmatrixCode{c, matrixToBitmatrix(k, m, w, cauchyOriginalMatrix(k, m, w))}
+	case CauchyGood:
+		return &goBitmatrixCode{c, matrixToBitmatrix(k, m, w, cauchyGoodMatrix(k, m, w))}
+	case Liberation:
+		return &goBitmatrixCode{c, liberationBitmatrix(k, w)}
+	case BlaumRoth:
+		return &goBitmatrixCode{c, blaumRothBitmatrix(k, w)}
+	case Liber8tion:
+		return &goBitmatrixCode{c, liber8tionBitmatrix(k)}
+	}
+	return nil
+}
+
+// goReedSolR6Code encodes a RAID-6 code with reedSolR6Encode.
+type goReedSolR6Code struct {
+	*goMatrixCode
+}
+
+func (this goReedSolR6Code) Encode(data, coding [][]byte) error {
+	reedSolR6Encode(this.w, data, coding)
+	return nil
+}
+
+// TestPureGoCompatible ensures that the Go codes produce the same coding
+// blocks as Jerasure and decode the blocks it produced.
+func TestPureGoCompatible(t *testing.T) {
+	tests := []struct {
+		technique           string
+		k, m, w, packetSize int
+	}{
+		{ReedSolVan, 6, 3, 8, 0},
+		{ReedSolVan, 4, 2, 16, 0},
+		{ReedSolVan, 3, 3, 32, 0},
+		{ReedSolR6, 6, 2, 8, 0},
+		{ReedSolR6, 5, 2, 16, 0},
+		{ReedSolR6, 4, 2, 32, 0},
+		{CauchyOrig, 6, 3, 4, 8},
+		{CauchyOrig, 5, 2, 13, 8},
+		{CauchyGood, 6, 2, 8, 16},
+		{CauchyGood, 7, 2, 11, 8},
+		{CauchyGood, 6, 3, 5, 8},
+		{CauchyGood, 4, 4, 12, 8},
+		{Liberation, 6, 2, 7, 16},
+		{BlaumRoth, 6, 2, 6, 16},
+		{Liber8tion, 6, 2, 8, 16},
+	}
+	rnd := rand.New(rand.NewSource(1))
+
+	for _, test := range tests {
+		k, m := test.k, test.m
+		multiple := int64(sizeInt * k * test.w * max(test.packetSize, 1))
+		bufferSize := 2 * multiple
+
+		cCode, err := NewCode(test.technique, k, m, test.w, test.packetSize, bufferSize)
+		if err != nil {
+			t.Fatalf("%+v: %v", test, err)
+		}
+		goCode := newGoCode(test.technique, k, m, test.w, test.packetSize, bufferSize)
+
+		data := allocateBuffers(k, bufferSize)
+		for i := range data {
+			rnd.Read(data[i])
+		}
+		cCoding := allocateBuffers(m, bufferSize)
+		goCoding := allocateBuffers(m, bufferSize)
+		if err = cCode.Encode(data, cCoding); err != nil {
+			t.Fatal(err)
+		}
+		if err = goCode.Encode(data, goCoding); err != nil {
+			t.Fatal(err)
+		}
+		for i := range cCoding {
+			if !bytes.Equal(cCoding[i], goCoding[i]) {
+				t.Errorf("%+v: coding block %d differs from jerasure", test, i)
+			}
+		}
+
+		// Erase as many data blocks as there are parities, and then the
+		// first data and coding blocks
+		lost := make([]int, m, m+1)
+		for i := range lost {
+			lost[i] = i
+		}
+		for _, erasures := range [][]int{append(lost, -1), {0, k, -1}} {
+			decoded := allocateBuffers(k, bufferSize)
+			decodedCoding := allocateBuffers(m, bufferSize)
+			for i := range data {
+				copy(decoded[i], data[i])
+			}
+			for i := range cCoding {
+				copy(decodedCoding[i], cCoding[i])
+			}
+			for _, id := range erasures[:len(erasures)-1] {
+				if id < k {
+					zero(decoded[id])
+				} else {
+					zero(decodedCoding[id-k])
+				}
+			}
+
+			if err = goCode.Decode(decoded, decodedCoding, erasures); err != nil {
+				t.Fatal(err)
+			}
+			for i := range data {
+				if !bytes.Equal(decoded[i], data[i]) {
+					t.Errorf("%+v: data block %d decoded from erasures %v differs", test, i, erasures)
+				}
+			}
+			for i := range cCoding {
+				if !bytes.Equal(decodedCoding[i], cCoding[i]) {
+					t.Errorf("%+v: coding block %d decoded from erasures %v differs", test, i, erasures)
+				}
+			}
+		}
+	}
+}
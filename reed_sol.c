@@ -1,3 +1,6 @@
+//go:build linux && !purego
+// +build linux,!purego
+
 /* reed_sol.c
  * James S. Plank
 
@@ -0,0 +1,94 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Stats counts the work done to encode or decode blocks, the way
+// jerasure_get_stats reports it. The RAID-6 encoder of ReedSolR6 codes
+// does not count its work.
+type Stats struct {
+	// XORBytes is the number of bytes XORed into other bytes.
+	XORBytes int64
+	// GFBytes is the number of bytes multiplied by a constant in
+	// GF(2^w).
+	GFBytes int64
+	// CopyBytes is the number of bytes copied.
+	CopyBytes int64
+}
+
+// Add returns the sum of two statistics.
+func (this Stats) Add(other Stats) Stats {
+	return Stats{
+		XORBytes:  this.XORBytes + other.XORBytes,
+		GFBytes:   this.GFBytes + other.GFBytes,
+		CopyBytes: this.CopyBytes + other.CopyBytes,
+	}
+}
+
+// counters accumulates the statistics of a code, which may be used by
+// several goroutines at once.
+type counters struct {
+	xorBytes, gfBytes, copyBytes int64
+}
+
+// add adds stats to the counters.
+func (this *counters) add(stats Stats) {
+	atomic.AddInt64(&this.xorBytes, stats.XORBytes)
+	atomic.AddInt64(&this.gfBytes, stats.GFBytes)
+	atomic.AddInt64(&this.copyBytes, stats.CopyBytes)
+}
+
+// load returns the accumulated statistics.
+func (this *counters) load() Stats {
+	return Stats{
+		XORBytes:  atomic.LoadInt64(&this.xorBytes),
+		GFBytes:   atomic.LoadInt64(&this.gfBytes),
+		CopyBytes: atomic.LoadInt64(&this.copyBytes),
+	}
+}
+
+// statsCoder is implemented by the codes of this package, which count
+// the work they do.
+type statsCoder interface {
+	encodeStats(data, coding [][]byte) (Stats, error)
+	decodeStats(data, coding [][]byte, erasures []int) (Stats, error)
+	record(stats Stats, err error) (Stats, error)
+}
+
+// EncodeStats encodes the data blocks with code, like code.Encode, and
+// returns the work that was done by this call. The work is also added to
+// the statistics returned by code.Stats. Comparing the statistics of
+// codes on the same data shows which code is the cheapest for it.
+func EncodeStats(code Coder, data, coding [][]byte) (Stats, error) {
+	c, ok := code.(statsCoder)
+	if !ok {
+		return Stats{}, fmt.Errorf("%w: %T does not count its work", ErrInvalidParams, code)
+	}
+	return c.record(c.encodeStats(data, coding))
+}
+
+// DecodeStats decodes the blocks with code, like code.Decode, and
+// returns the work that was done by this call.
+func DecodeStats(code Coder, data, coding [][]byte, erasures []int) (Stats, error) {
+	c, ok := code.(statsCoder)
+	if !ok {
+		return Stats{}, fmt.Errorf("%w: %T does not count its work", ErrInvalidParams, code)
+	}
+	return c.record(c.decodeStats(data, coding, erasures))
+}
@@ -0,0 +1,152 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// TestExecution ensures that every execution of a bitmatrix code produces
+// the same coding blocks and decodes the blocks of the others.
+func TestExecution(t *testing.T) {
+	tests := []struct {
+		technique string
+		k, m, w   int
+	}{
+		{CauchyOrig, 6, 2, 8},
+		{CauchyGood, 5, 3, 8},
+		{Liberation, 6, 2, 7},
+		{BlaumRoth, 4, 2, 6},
+		{Liber8tion, 4, 2, 8},
+	}
+	executions := []Execution{SmartSchedule, DumbSchedule, NoSchedule}
+	rnd := rand.New(rand.NewSource(1))
+
+	for _, test := range tests {
+		k, m, packetSize := test.k, test.m, 16
+		bufferSize := int64(2 * sizeInt * k * test.w * packetSize)
+		data := allocateBuffers(k, bufferSize)
+		for i := range data {
+			rnd.Read(data[i])
+		}
+
+		var want [][]byte
+		for _, execution := range executions {
+			code, err := bitmatrixConstructors[test.technique](k, m, test.w, packetSize, bufferSize, WithExecution(execution))
+			if err != nil {
+				t.Fatalf("%s execution %d: %v", test.technique, execution, err)
+			}
+			coding := allocateBuffers(m, bufferSize)
+			if err = code.Encode(data, coding); err != nil {
+				t.Fatal(err)
+			}
+			if want == nil {
+				want = coding
+			}
+			for i := range coding {
+				if !bytes.Equal(coding[i], want[i]) {
+					t.Errorf("%s execution %d: coding block %d differs", test.technique, execution, i)
+				}
+			}
+
+			decoded := allocateBuffers(k, bufferSize)
+			for i := 1; i < k; i++ {
+				copy(decoded[i], data[i])
+			}
+			decodedCoding := allocateBuffers(m, bufferSize)
+			copy(decodedCoding[1], want[1])
+			if err = code.Decode(decoded, decodedCoding, []int{0, k, -1}); err != nil {
+				t.Fatalf("%s execution %d: %v", test.technique, execution, err)
+			}
+			if !bytes.Equal(decoded[0], data[0]) || !bytes.Equal(decodedCoding[0], want[0]) {
+				t.Errorf("%s execution %d: erased blocks were not restored", test.technique, execution)
+			}
+			code.Close()
+		}
+	}
+
+	if _, err := NewCauchyGoodCode(6, 2, 8, 16, 0, WithExecution(Execution(7))); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("unknown execution: expected ErrInvalidParams, got %v", err)
+	}
+}
+
+// bitmatrixConstructors holds the constructors that accept CodeOptions.
+var bitmatrixConstructors = map[string]func(k, m, w, packetSize int, bufferSize int64, opts ...CodeOption) (Coder, error){
+	CauchyOrig: NewCauchyOrigCode,
+	CauchyGood: NewCauchyGoodCode,
+	Liberation: NewLiberationCode,
+	BlaumRoth:  NewBlaumRothCode,
+	Liber8tion: NewLiber8tionCode,
+}
+
+func TestStats(t *testing.T) {
+	k, m, w, packetSize := 6, 2, 8, 16
+	bufferSize := int64(sizeInt * k * w * packetSize)
+	data := allocateBuffers(k, bufferSize)
+	rand.New(rand.NewSource(1)).Read(data[0])
+	coding := allocateBuffers(m, bufferSize)
+
+	encode := func(technique string) Stats {
+		code, err := NewCode(technique, k, m, w, packetSize, bufferSize)
+		if err != nil {
+			t.Fatalf("%s: %v", technique, err)
+		}
+		defer code.Close()
+		stats, err := EncodeStats(code, data, coding)
+		if err != nil {
+			t.Fatalf("%s: %v", technique, err)
+		}
+		return stats
+	}
+
+	if stats := encode(ReedSolVan); stats.GFBytes == 0 {
+		t.Errorf("ReedSolVan: expected multiplications, got %+v", stats)
+	}
+	orig, good := encode(CauchyOrig), encode(CauchyGood)
+	if orig.GFBytes != 0 || good.GFBytes != 0 {
+		t.Errorf("Cauchy codes should not multiply, got %+v and %+v", orig, good)
+	}
+	if good.XORBytes >= orig.XORBytes {
+		t.Errorf("CauchyGood should XOR less than CauchyOrig, got %d and %d bytes", good.XORBytes, orig.XORBytes)
+	}
+
+	code, err := NewCauchyGoodCode(k, m, w, packetSize, bufferSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer code.Close()
+	if stats := code.Stats(); stats != (Stats{}) {
+		t.Errorf("expected a new code to have done no work, got %+v", stats)
+	}
+	if err = code.Encode(data, coding); err != nil {
+		t.Fatal(err)
+	}
+	if stats := code.Stats(); stats != good {
+		t.Errorf("expected the encode to do %+v, got %+v", good, stats)
+	}
+	stats, err := DecodeStats(code, data, coding, []int{0, 1, -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.XORBytes == 0 || stats.CopyBytes == 0 {
+		t.Errorf("expected the decode to XOR and copy, got %+v", stats)
+	}
+	if sum := good.Add(stats); code.Stats() != sum {
+		t.Errorf("expected the code to have done %+v, got %+v", sum, code.Stats())
+	}
+}
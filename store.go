@@ -0,0 +1,315 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ShardStore stores the shards of stripes, which are the data and coding
+// blocks read and written by Encode and Decode, along with the manifests
+// of the stripes. Shards are named by slash separated paths, such as
+// "testfiles/stripe_k0".
+//
+// Shards that do not exist are reported with errors that match
+// fs.ErrNotExist.
+type ShardStore interface {
+	// Open opens a shard for reading.
+	Open(name string) (io.ReadSeekCloser, error)
+	// Create creates a shard for writing. The shard only replaces any
+	// existing shard of the same name when it is committed.
+	Create(name string) (ShardWriter, error)
+	// Stat returns the size of a shard.
+	Stat(name string) (size int64, err error)
+	// Delete removes a shard.
+	Delete(name string) error
+	// List returns the sorted names of the shards that start with
+	// prefix.
+	List(prefix string) ([]string, error)
+}
+
+// ShardWriter writes a shard created by a ShardStore.
+type ShardWriter interface {
+	io.Writer
+	// Sync flushes the written bytes to stable storage. Once a synced
+	// shard is committed, it is durable as well.
+	Sync() error
+	// Commit makes the shard visible under its name, replacing any
+	// existing shard.
+	Commit() error
+	// Close releases the shard. A shard that was not committed is
+	// discarded.
+	Close() error
+}
+
+// NewDirStore returns a ShardStore that stores every shard as a file,
+// named by joining root and the name of the shard. An empty root stores
+// the shards relative to the working directory, which is the default
+// store of Encode and Decode.
+//
+// The directories of shards are created as they are needed. Shards are
+// written to temporary files with a .tmp suffix, which are renamed when
+// the shards are committed, so a failed encode or decode never leaves
+// partial shards behind.
+func NewDirStore(root string) ShardStore {
+	return &dirStore{root}
+}
+
+type dirStore struct {
+	root string
+}
+
+// path returns the path of the file of a shard.
+func (this *dirStore) path(name string) string {
+	return filepath.Join(this.root, filepath.FromSlash(name))
+}
+
+func (this *dirStore) Open(name string) (io.ReadSeekCloser, error) {
+	return os.Open(this.path(name))
+}
+
+func (this *dirStore) Create(name string) (ShardWriter, error) {
+	name = this.path(name)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.Create(name + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &dirShardWriter{file: file, name: name}, nil
+}
+
+func (this *dirStore) Stat(name string) (int64, error) {
+	fi, err := os.Stat(this.path(name))
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+func (this *dirStore) Delete(name string) error {
+	return os.Remove(this.path(name))
+}
+
+// List lists the files in the directory of prefix. Temporary files of
+// shards that were not committed are left out.
+func (this *dirStore) List(prefix string) ([]string, error) {
+	dir, base := path.Split(prefix)
+	entries, err := os.ReadDir(this.path(dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasSuffix(entry.Name(), ".tmp") {
+			continue
+		}
+		if strings.HasPrefix(entry.Name(), base) {
+			names = append(names, dir+entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// dirShardWriter writes a shard to a temporary file, which is renamed
+// to the file of the shard on Commit.
+type dirShardWriter struct {
+	file *os.File
+	// name is the path of the file of the shard.
+	name string
+	// synced is set once the file was flushed, after which the
+	// directory is flushed on Commit as well.
+	synced    bool
+	committed bool
+}
+
+func (this *dirShardWriter) Write(buf []byte) (int, error) {
+	return this.file.Write(buf)
+}
+
+func (this *dirShardWriter) Sync() error {
+	this.synced = true
+	return this.file.Sync()
+}
+
+func (this *dirShardWriter) Commit() error {
+	if err := this.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(this.file.Name(), this.name); err != nil {
+		return err
+	}
+	this.committed = true
+	if this.synced {
+		return syncDir(filepath.Dir(this.name))
+	}
+	return nil
+}
+
+func (this *dirShardWriter) Close() error {
+	if this.committed {
+		return nil
+	}
+	err := this.file.Close()
+	os.Remove(this.file.Name())
+	return err
+}
+
+// MemStore is a ShardStore that holds the shards in memory, which is
+// mostly useful for tests. It may be used by several goroutines at
+// once.
+type MemStore struct {
+	mu     sync.Mutex
+	shards map[string][]byte
+}
+
+// NewMemStore returns an empty MemStore.
+func NewMemStore() *MemStore {
+	return &MemStore{shards: make(map[string][]byte)}
+}
+
+// get looks up a shard under the lock and returns its contents.
+// Committed contents are never changed, so the caller may read them
+// after the lock is released.
+func (this *MemStore) get(op, name string) ([]byte, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	buf, ok := this.shards[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return buf, nil
+}
+
+func (this *MemStore) Open(name string) (io.ReadSeekCloser, error) {
+	buf, err := this.get("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return memShard{bytes.NewReader(buf)}, nil
+}
+
+func (this *MemStore) Create(name string) (ShardWriter, error) {
+	return &memShardWriter{store: this, name: name}, nil
+}
+
+func (this *MemStore) Stat(name string) (int64, error) {
+	buf, err := this.get("stat", name)
+	return int64(len(buf)), err
+}
+
+func (this *MemStore) Delete(name string) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if _, ok := this.shards[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(this.shards, name)
+	return nil
+}
+
+func (this *MemStore) List(prefix string) ([]string, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	var names []string
+	for name := range this.shards {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// memShard reads a shard of a MemStore.
+type memShard struct {
+	*bytes.Reader
+}
+
+func (this memShard) Close() error {
+	return nil
+}
+
+// memShardWriter buffers a shard until it is committed to its MemStore.
+type memShardWriter struct {
+	store *MemStore
+	name  string
+	buf   bytes.Buffer
+}
+
+func (this *memShardWriter) Write(buf []byte) (int, error) {
+	return this.buf.Write(buf)
+}
+
+func (this *memShardWriter) Sync() error {
+	return nil
+}
+
+func (this *memShardWriter) Commit() error {
+	this.store.mu.Lock()
+	defer this.store.mu.Unlock()
+	this.store.shards[this.name] = append([]byte(nil), this.buf.Bytes()...)
+	return nil
+}
+
+func (this *memShardWriter) Close() error {
+	this.buf.Reset()
+	return nil
+}
+
+// shardReader reads a shard of a known size.
+type shardReader struct {
+	io.ReadSeekCloser
+	size int64
+}
+
+// openShard opens a shard of a store for reading.
+func openShard(store ShardStore, name string) (*shardReader, error) {
+	size, err := store.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	shard, err := store.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &shardReader{shard, size}, nil
+}
+
+// Len returns the size of the shard.
+func (this *shardReader) Len() int64 {
+	return this.size
+}
+
+// closeBlocks closes the blocks that were opened.
+func closeBlocks(blocks []LenReader) {
+	for _, block := range blocks {
+		if c, ok := block.(io.Closer); ok {
+			c.Close()
+		}
+	}
+}
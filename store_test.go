@@ -0,0 +1,152 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// writeTestShard writes and commits a shard.
+func writeTestShard(t *testing.T, store ShardStore, name string, buf []byte) {
+	if err := writeShard(store, name, buf, SyncNone); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readTestShard reads a whole shard.
+func readTestShard(store ShardStore, name string) ([]byte, error) {
+	shard, err := store.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer shard.Close()
+	return io.ReadAll(shard)
+}
+
+func TestShardStores(t *testing.T) {
+	for name, store := range map[string]ShardStore{"dir": NewDirStore(t.TempDir()), "mem": NewMemStore()} {
+		writeTestShard(t, store, "stripe_k0", []byte{1, 2, 3})
+		writeTestShard(t, store, "stripe_m0", []byte{4, 5})
+		writeTestShard(t, store, "other_k0", []byte{6})
+
+		// Shards are only visible once they are committed
+		shard, err := store.Create("stripe_k1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = shard.Write([]byte{7}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = store.Stat("stripe_k1"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s: expected an uncommitted shard not to exist, got %v", name, err)
+		}
+		names, err := store.List("stripe_")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"stripe_k0", "stripe_m0"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("%s: expected shards %v, got %v", name, want, names)
+		}
+		if err = shard.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		size, err := store.Stat("stripe_k0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size != 3 {
+			t.Errorf("%s: expected a shard of 3 bytes, got %d", name, size)
+		}
+		buf, err := readTestShard(store, "stripe_m0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf, []byte{4, 5}) {
+			t.Errorf("%s: unexpected shard %v", name, buf)
+		}
+
+		// Committing replaces a shard
+		writeTestShard(t, store, "stripe_m0", []byte{8})
+		if buf, _ = readTestShard(store, "stripe_m0"); !bytes.Equal(buf, []byte{8}) {
+			t.Errorf("%s: expected the shard to be replaced, got %v", name, buf)
+		}
+
+		if err = store.Delete("stripe_k0"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = store.Open("stripe_k0"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s: expected a deleted shard not to exist, got %v", name, err)
+		}
+		if err = store.Delete("stripe_k0"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s: expected deleting a missing shard to fail, got %v", name, err)
+		}
+		if names, _ = store.List(""); !reflect.DeepEqual(names, []string{"other_k0", "stripe_m0"}) {
+			t.Errorf("%s: unexpected shards %v", name, names)
+		}
+	}
+}
+
+// TestMemStore encodes and decodes a stripe without touching the disc.
+func TestMemStore(t *testing.T) {
+	k, m := 4, 2
+	code, err := NewCauchyGoodCode(k, m, 8, 16, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer code.Close()
+
+	store := NewMemStore()
+	blocks := make([][]byte, k)
+	for i := range blocks {
+		blocks[i] = make([]byte, 3*4096+100)
+		rand.Read(blocks[i])
+		writeTestShard(t, store, fmt.Sprintf("stripe_k%d", i), blocks[i])
+	}
+
+	if err = Encode("stripe", code, WithStore(store)); err != nil {
+		t.Fatal(err)
+	}
+	names, _ := store.List("stripe")
+	if len(names) != k+m+1 {
+		t.Fatalf("expected %d blocks and a manifest, got %v", k+m, names)
+	}
+	if _, err = ReadManifest("stripe", WithStore(store)); err != nil {
+		t.Fatal(err)
+	}
+
+	store.Delete("stripe_k1")
+	store.Delete("stripe_m1")
+	if err = DecodeStripe("stripe", WithStore(store), WithWorkers(2)); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := readTestShard(store, "stripe_k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, blocks[1]) {
+		t.Error("decoded data block differs from the original")
+	}
+	if _, err = store.Stat("stripe_m1"); err != nil {
+		t.Errorf("expected the coding block to be regenerated, got %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"fmt"
+	"io"
+)
+
+// StreamEncoder splits a single stream of data across the k data shards
+// of a code and writes the m coding shards as it goes.
+//
+// The stream is consumed in rounds of k buffers. Data shard i receives
+// buffer i of every round, so each shard grows by the code's buffer size
+// per round. The last round is padded with zeros, which means that the
+// length of the stream has to be kept to strip the padding again.
+type StreamEncoder struct {
+	code   Coder
+	src    io.Reader
+	dst    []io.Writer
+	data   [][]byte
+	coding [][]byte
+}
+
+// NewStreamEncoder returns a StreamEncoder that reads from src and
+// writes the k data shards followed by the m coding shards to dst.
+//
+// The code must have a non-zero buffer size that is aligned to its
+// coding parameters.
+func NewStreamEncoder(code Coder, src io.Reader, dst []io.Writer) (*StreamEncoder, error) {
+	if err := checkStreamCode(code); err != nil {
+		return nil, err
+	}
+	if len(dst) != code.K()+code.M() {
+		return nil, fmt.Errorf("%w: got %d shard writers, want %d", ErrInvalidParams, len(dst), code.K()+code.M())
+	}
+	return &StreamEncoder{
+		code:   code,
+		src:    src,
+		dst:    dst,
+		data:   allocateBuffers(code.K(), code.Buffersize()),
+		coding: allocateBuffers(code.M(), code.Buffersize()),
+	}, nil
+}
+
+// Encode reads src until EOF, writing every round of data and coding
+// buffers to the shards. It returns the number of bytes read from src.
+func (this *StreamEncoder) Encode() (n int64, err error) {
+	k := len(this.data)
+	eof := false
+
+	for !eof {
+		read := 0
+
+		for j := 0; j < k; j++ {
+			if eof {
+				zero(this.data[j])
+				continue
+			}
+			var r int
+			r, eof, err = readBuffer(this.src, this.data[j])
+			if err != nil {
+				return n, err
+			}
+			read += r
+		}
+		n += int64(read)
+
+		// A stream that ends on a round boundary needs no padding round.
+		if read == 0 {
+			break
+		}
+
+		if err = this.code.Encode(this.data, this.coding); err != nil {
+			return n, err
+		}
+		if err = this.write(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// write writes the current round of data and coding buffers to the
+// shard writers.
+func (this *StreamEncoder) write() error {
+	k := len(this.data)
+	for j := range this.data {
+		if _, err := this.dst[j].Write(this.data[j]); err != nil {
+			return err
+		}
+	}
+	for j := range this.coding {
+		if _, err := this.dst[k+j].Write(this.coding[j]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkStreamCode ensures that a code can be used for streaming, which
+// requires a fixed buffer size that is aligned to the coding parameters.
+func checkStreamCode(code Coder) error {
+	if code.Buffersize() <= 0 {
+		return fmt.Errorf("%w: streaming requires a buffer size > 0", ErrInvalidParams)
+	}
+	return code.CheckFileSize(code.Buffersize())
+}
+
+// streamDecoder joins the data shards written by a StreamEncoder back
+// into the original stream, decoding missing shards as it goes.
+type streamDecoder struct {
+	code      Coder
+	shards    []io.Reader
+	erasures  []int
+	remaining int64
+	data      [][]byte
+	coding    [][]byte
+	// out holds the decoded data of the current round and pending the
+	// part of it that has not been read yet.
+	out     []byte
+	pending []byte
+	err     error
+}
+
+// NewStreamDecoder returns a reader that reconstructs the original
+// stream of size bytes from the shards of a StreamEncoder.
+//
+// The shards are given in the same order as to the encoder, with nil
+// for shards that are missing. Any k of the k+m shards are enough to
+// reconstruct the stream. Errors, such as ErrTooManyErasures, are
+// returned by Read.
+func NewStreamDecoder(code Coder, shards []io.Reader, size int64) io.Reader {
+	this := &streamDecoder{
+		code:      code,
+		shards:    shards,
+		remaining: size,
+	}
+
+	if this.err = checkStreamCode(code); this.err != nil {
+		return this
+	}
+	if len(shards) != code.K()+code.M() {
+		this.err = fmt.Errorf("%w: got %d shard readers, want %d", ErrInvalidParams, len(shards), code.K()+code.M())
+		return this
+	}
+	if size < 0 {
+		this.err = fmt.Errorf("%w: size < 0", ErrInvalidParams)
+		return this
+	}
+
+	// The extra int is required for the -1 stopper
+	this.erasures = make([]int, 0, len(shards)+1)
+	for i, shard := range shards {
+		if shard == nil {
+			this.erasures = append(this.erasures, i)
+		}
+	}
+	if len(this.erasures) > code.M() {
+		this.err = fmt.Errorf("%w: found %d erasures with only %d parities", ErrTooManyErasures, len(this.erasures), code.M())
+		return this
+	}
+	this.erasures = append(this.erasures, -1)
+
+	this.data = allocateBuffers(code.K(), code.Buffersize())
+	this.coding = allocateBuffers(code.M(), code.Buffersize())
+	this.out = make([]byte, int64(code.K())*code.Buffersize())
+	return this
+}
+
+// Read reads decoded data from the stream.
+func (this *streamDecoder) Read(p []byte) (n int, err error) {
+	for len(this.pending) == 0 {
+		if this.err != nil {
+			return 0, this.err
+		}
+		if this.remaining == 0 {
+			return 0, io.EOF
+		}
+		this.err = this.decode()
+	}
+
+	n = copy(p, this.pending)
+	this.pending = this.pending[n:]
+	return n, nil
+}
+
+// decode reads the next round of buffers from the shards, decodes the
+// erased ones and queues the data for reading with the padding removed.
+func (this *streamDecoder) decode() error {
+	k := len(this.data)
+
+	for j, shard := range this.shards {
+		if shard == nil {
+			continue
+		}
+
+		buf := this.data
+		if j >= k {
+			buf = this.coding
+			j -= k
+		}
+
+		n, _, err := readBuffer(shard, buf[j])
+		if err != nil {
+			return err
+		}
+		if n < len(buf[j]) {
+			return io.ErrUnexpectedEOF
+		}
+	}
+
+	if len(this.erasures) > 1 {
+		if err := this.code.Decode(this.data, this.coding, this.erasures); err != nil {
+			return err
+		}
+	}
+
+	size := int64(0)
+	for j := range this.data {
+		size += int64(copy(this.out[size:], this.data[j]))
+	}
+	if size > this.remaining {
+		size = this.remaining
+	}
+	this.remaining -= size
+	this.pending = this.out[:size]
+	return nil
+}
@@ -0,0 +1,176 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+// streamEncode encodes src into k+m in-memory shards.
+func streamEncode(t *testing.T, code Coder, src []byte) []*bytes.Buffer {
+	shards := make([]*bytes.Buffer, code.K()+code.M())
+	dst := make([]io.Writer, len(shards))
+	for i := range shards {
+		shards[i] = new(bytes.Buffer)
+		dst[i] = shards[i]
+	}
+
+	enc, err := NewStreamEncoder(code, bytes.NewReader(src), dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := enc.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(src)) {
+		t.Fatalf("encoded %d bytes, expected %d", n, len(src))
+	}
+	return shards
+}
+
+func TestStreamEncoder(t *testing.T) {
+	k, m := 4, 2
+	buffersize := int64(1024)
+	code, err := NewReedSolVanCode(k, m, 8, 0, buffersize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []int{0, 1, 4096, 4096*3 + 17} {
+		src := make([]byte, size)
+		rand.Read(src)
+
+		shards := streamEncode(t, code, src)
+
+		rounds := (int64(size) + int64(k)*buffersize - 1) / (int64(k) * buffersize)
+		for i, shard := range shards {
+			if int64(shard.Len()) != rounds*buffersize {
+				t.Fatalf("size %d: shard %d has %d bytes, expected %d", size, i, shard.Len(), rounds*buffersize)
+			}
+		}
+
+		// Recompute every round from the source and compare it to the shards.
+		padded := make([]byte, rounds*int64(k)*buffersize)
+		copy(padded, src)
+		for r := int64(0); r < rounds; r++ {
+			data := allocateBuffers(k, buffersize)
+			coding := allocateBuffers(m, buffersize)
+			for j := range data {
+				off := (r*int64(k) + int64(j)) * buffersize
+				copy(data[j], padded[off:off+buffersize])
+			}
+			if err = code.Encode(data, coding); err != nil {
+				t.Fatal(err)
+			}
+			for j, buf := range append(data, coding...) {
+				got := shards[j].Bytes()[r*buffersize : (r+1)*buffersize]
+				if !bytes.Equal(got, buf) {
+					t.Fatalf("size %d: shard %d differs in round %d", size, j, r)
+				}
+			}
+		}
+	}
+}
+
+func TestStreamEncoderParams(t *testing.T) {
+	code, err := NewReedSolVanCode(4, 2, 8, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewStreamEncoder(code, nil, make([]io.Writer, 6)); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("expected ErrInvalidParams for a zero buffer size, got %v", err)
+	}
+
+	code, err = NewReedSolVanCode(4, 2, 8, 0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewStreamEncoder(code, nil, make([]io.Writer, 6)); !errors.Is(err, ErrMisalignedBuffer) {
+		t.Errorf("expected ErrMisalignedBuffer, got %v", err)
+	}
+
+	code, err = NewReedSolVanCode(4, 2, 8, 0, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewStreamEncoder(code, nil, make([]io.Writer, 4)); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("expected ErrInvalidParams for missing shard writers, got %v", err)
+	}
+}
+
+func TestStreamDecoder(t *testing.T) {
+	k, m := 6, 2
+	buffersize := int64(258048)
+	code, err := NewLiberationCode(k, m, 7, 128, buffersize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	size := int(buffersize)*k*2 + 1234
+	src := make([]byte, size)
+	rand.Read(src)
+
+	shards := streamEncode(t, code, src)
+
+	for _, missing := range [][]int{{}, {1}, {0, 5}, {3, 7}, {6, 7}} {
+		readers := make([]io.Reader, len(shards))
+		for i := range shards {
+			readers[i] = bytes.NewReader(shards[i].Bytes())
+		}
+		for _, i := range missing {
+			readers[i] = nil
+		}
+
+		got, err := io.ReadAll(NewStreamDecoder(code, readers, int64(size)))
+		if err != nil {
+			t.Fatalf("missing %v: %v", missing, err)
+		}
+		if !bytes.Equal(got, src) {
+			t.Fatalf("missing %v: decoded stream differs from the source", missing)
+		}
+	}
+}
+
+func TestStreamDecoderErrors(t *testing.T) {
+	k, m := 4, 2
+	buffersize := int64(1024)
+	code, err := NewReedSolVanCode(k, m, 8, 0, buffersize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := make([]byte, 5000)
+	shards := streamEncode(t, code, src)
+
+	readers := make([]io.Reader, len(shards))
+	readers[0] = bytes.NewReader(shards[0].Bytes())
+	readers[1] = bytes.NewReader(shards[1].Bytes())
+	readers[2] = bytes.NewReader(shards[2].Bytes())
+	if _, err = io.ReadAll(NewStreamDecoder(code, readers, int64(len(src)))); !errors.Is(err, ErrTooManyErasures) {
+		t.Errorf("expected ErrTooManyErasures, got %v", err)
+	}
+
+	for i := range shards {
+		readers[i] = bytes.NewReader(shards[i].Bytes()[:buffersize])
+	}
+	if _, err = io.ReadAll(NewStreamDecoder(code, readers, int64(len(src)))); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF for truncated shards, got %v", err)
+	}
+}
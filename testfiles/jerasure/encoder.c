@@ -0,0 +1,250 @@
+/* encoder.c
+ * James S. Plank
+
+Jerasure - A C/C++ Library for a Variety of Reed-Solomon and RAID-6 Erasure Coding Techniques
+Copright (C) 2007 James S. Plank
+
+This library is free software; you can redistribute it and/or
+modify it under the terms of the GNU Lesser General Public
+License as published by the Free Software Foundation; either
+version 2.1 of the License, or (at your option) any later version.
+
+This library is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public
+License along with this library; if not, write to the Free Software
+Foundation, Inc., 51 Franklin Street, Fifth Floor, Boston, MA  02110-1301  USA
+*/
+
+/* goerasure: the encoding path of Examples/encoder.c of jerasure 1.2,
+ * which is not vendored with the library: argument handling, buffer size
+ * rounding, padding, block naming and the meta file. The timing and
+ * statistics output is left out. It is built against the jerasure
+ * sources at the root of goerasure by generate.sh, which produces the
+ * fixtures of jerasurefile_test.go. */
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <errno.h>
+#include <sys/stat.h>
+#include <unistd.h>
+#include "jerasure.h"
+#include "reed_sol.h"
+#include "cauchy.h"
+#include "liberation.h"
+
+enum Coding_Technique {Reed_Sol_Van, Reed_Sol_R6_Op, Cauchy_Orig, Cauchy_Good, Liberation, Blaum_Roth, Liber8tion, RDP, EVENODD, No_Coding};
+
+static int jfread(void *ptr, int size, int nmembers, FILE *stream) {
+  int nd; int *li, i;
+  if (stream != NULL) return fread(ptr, size, nmembers, stream);
+  nd = size/sizeof(int); li = (int *) ptr;
+  for (i = 0; i < nd; i++) li[i] = lrand48();
+  return size;
+}
+
+int main(int argc, char **argv) {
+  FILE *fp, *fp2;
+  char *block;
+  int size, newsize;
+  int buffersize;
+  int k, m, w, packetsize;
+  enum Coding_Technique tech;
+  int i;
+  int blocksize;
+  int total;
+  int extra;
+  char **data, **coding;
+  int *matrix = NULL, *bitmatrix = NULL, **schedule = NULL;
+  char *s1, *s2, *extension, *fname, temp[64];
+  int md, n, readins, up, down;
+  struct stat status;
+
+  if (argc != 8) {
+    fprintf(stderr, "usage: inputfile k m coding_technique w packetsize buffersize\n");
+    exit(0);
+  }
+  k = atoi(argv[2]); m = atoi(argv[3]); w = atoi(argv[5]);
+  packetsize = atoi(argv[6]); buffersize = atoi(argv[7]);
+
+  /* Determine proper buffersize by finding the closest valid buffersize to the input value */
+  if (buffersize != 0) {
+    if (packetsize != 0 && buffersize%(sizeof(int)*w*k*packetsize) != 0) {
+      up = buffersize;
+      down = buffersize;
+      while (up%(sizeof(int)*w*k*packetsize) != 0 && (down%(sizeof(int)*w*k*packetsize) != 0)) {
+        up++;
+        if (down == 0) {
+          down--;
+        }
+      }
+      if (up%(sizeof(int)*w*k*packetsize) == 0) {
+        buffersize = up;
+      } else {
+        if (down != 0) {
+          buffersize = down;
+        }
+      }
+    } else if (packetsize == 0 && buffersize%(sizeof(int)*w*k) != 0) {
+      up = buffersize;
+      down = buffersize;
+      while (up%(sizeof(int)*w*k) != 0 && down%(sizeof(int)*w*k) != 0) {
+        up++;
+        down--;
+      }
+      if (up%(sizeof(int)*w*k) == 0) {
+        buffersize = up;
+      } else {
+        buffersize = down;
+      }
+    }
+  }
+
+  if (strcmp(argv[4], "reed_sol_van") == 0) tech = Reed_Sol_Van;
+  else if (strcmp(argv[4], "reed_sol_r6_op") == 0) tech = Reed_Sol_R6_Op;
+  else if (strcmp(argv[4], "cauchy_orig") == 0) tech = Cauchy_Orig;
+  else if (strcmp(argv[4], "cauchy_good") == 0) tech = Cauchy_Good;
+  else if (strcmp(argv[4], "liberation") == 0) tech = Liberation;
+  else if (strcmp(argv[4], "blaum_roth") == 0) tech = Blaum_Roth;
+  else if (strcmp(argv[4], "liber8tion") == 0) tech = Liber8tion;
+  else { fprintf(stderr, "Not a valid coding technique.\n"); exit(1); }
+
+  fp = fopen(argv[1], "rb");
+  if (fp == NULL) { fprintf(stderr, "Unable to open file.\n"); exit(1); }
+  i = mkdir("Coding", S_IRWXU);
+  if (i == -1 && errno != EEXIST) { fprintf(stderr, "Unable to create Coding directory.\n"); exit(1); }
+  stat(argv[1], &status);
+  size = status.st_size;
+
+  newsize = size;
+
+  /* Find new size by determining next closest multiple */
+  if (packetsize != 0) {
+    if (size%(k*w*packetsize*sizeof(int)) != 0) {
+      while (newsize%(k*w*packetsize*sizeof(int)) != 0)
+        newsize++;
+    }
+  } else {
+    if (size%(k*w*sizeof(int)) != 0) {
+      while (newsize%(k*w*sizeof(int)) != 0)
+        newsize++;
+    }
+  }
+
+  if (buffersize != 0) {
+    while (newsize%buffersize != 0) {
+      newsize++;
+    }
+  }
+
+  /* Determine size of k+m files */
+  blocksize = newsize/k;
+
+  /* Allow for buffersize and determine number of read-ins */
+  if (size > buffersize && buffersize != 0) {
+    readins = newsize/buffersize;
+    block = (char *)malloc(sizeof(char)*buffersize);
+    blocksize = buffersize/k;
+  } else {
+    readins = 1;
+    buffersize = size;
+    block = (char *)malloc(sizeof(char)*newsize);
+  }
+
+  /* Break inputfile name into the filename and extension */
+  s1 = (char*)malloc(sizeof(char)*(strlen(argv[1])+20));
+  s2 = strrchr(argv[1], '/');
+  if (s2 != NULL) { s2++; strcpy(s1, s2); } else { strcpy(s1, argv[1]); }
+  s2 = strchr(s1, '.');
+  if (s2 != NULL) { extension = strdup(s2); *s2 = '\0'; } else { extension = strdup(""); }
+
+  fname = (char*)malloc(sizeof(char)*(strlen(argv[1])+20+20));
+  sprintf(temp, "%d", k);
+  md = strlen(temp);
+
+  data = (char **)malloc(sizeof(char*)*k);
+  coding = (char **)malloc(sizeof(char*)*m);
+  for (i = 0; i < m; i++) coding[i] = (char *)malloc(sizeof(char)*blocksize);
+
+  switch(tech) {
+    case Reed_Sol_Van: matrix = reed_sol_vandermonde_coding_matrix(k, m, w); break;
+    case Reed_Sol_R6_Op: break;
+    case Cauchy_Orig:
+      matrix = cauchy_original_coding_matrix(k, m, w);
+      bitmatrix = jerasure_matrix_to_bitmatrix(k, m, w, matrix);
+      schedule = jerasure_smart_bitmatrix_to_schedule(k, m, w, bitmatrix);
+      break;
+    case Cauchy_Good:
+      matrix = cauchy_good_general_coding_matrix(k, m, w);
+      bitmatrix = jerasure_matrix_to_bitmatrix(k, m, w, matrix);
+      schedule = jerasure_smart_bitmatrix_to_schedule(k, m, w, bitmatrix);
+      break;
+    case Liberation:
+      bitmatrix = liberation_coding_bitmatrix(k, w);
+      schedule = jerasure_smart_bitmatrix_to_schedule(k, m, w, bitmatrix);
+      break;
+    case Blaum_Roth:
+      bitmatrix = blaum_roth_coding_bitmatrix(k, w);
+      schedule = jerasure_smart_bitmatrix_to_schedule(k, m, w, bitmatrix);
+      break;
+    case Liber8tion:
+      bitmatrix = liber8tion_coding_bitmatrix(k);
+      schedule = jerasure_smart_bitmatrix_to_schedule(k, m, w, bitmatrix);
+      break;
+    default: break;
+  }
+
+  n = 1;
+  total = 0;
+  while (n <= readins) {
+    /* Check if padding is needed, if so, add appropriate number of zeros */
+    if (total < size && total+buffersize <= size) {
+      total += jfread(block, sizeof(char), buffersize, fp);
+    } else if (total < size && total+buffersize > size) {
+      extra = jfread(block, sizeof(char), buffersize, fp);
+      for (i = extra; i < buffersize; i++) {
+        block[i] = '0';
+      }
+    } else if (total == size) {
+      for (i = 0; i < buffersize; i++) {
+        block[i] = '0';
+      }
+    }
+
+    for (i = 0; i < k; i++) data[i] = block+(i*blocksize);
+
+    switch(tech) {
+      case Reed_Sol_Van: jerasure_matrix_encode(k, m, w, matrix, data, coding, blocksize); break;
+      case Reed_Sol_R6_Op: reed_sol_r6_encode(k, w, data, coding, blocksize); break;
+      default: jerasure_schedule_encode(k, m, w, schedule, data, coding, blocksize, packetsize); break;
+    }
+
+    for (i = 1; i <= k; i++) {
+      sprintf(fname, "Coding/%s_k%0*d%s", s1, md, i, extension);
+      fp2 = fopen(fname, n == 1 ? "wb" : "ab");
+      fwrite(data[i-1], sizeof(char), blocksize, fp2);
+      fclose(fp2);
+    }
+    for (i = 1; i <= m; i++) {
+      sprintf(fname, "Coding/%s_m%0*d%s", s1, md, i, extension);
+      fp2 = fopen(fname, n == 1 ? "wb" : "ab");
+      fwrite(coding[i-1], sizeof(char), blocksize, fp2);
+      fclose(fp2);
+    }
+    n++;
+  }
+
+  sprintf(fname, "Coding/%s_meta.txt", s1);
+  fp2 = fopen(fname, "wb");
+  fprintf(fp2, "%s\n", argv[1]);
+  fprintf(fp2, "%d\n", size);
+  fprintf(fp2, "%d %d %d %d %d\n", k, m, w, packetsize, buffersize);
+  fprintf(fp2, "%s\n", argv[4]);
+  fprintf(fp2, "%d\n", tech);
+  fprintf(fp2, "%d\n", readins);
+  fclose(fp2);
+  return 0;
+}
@@ -0,0 +1,44 @@
+#!/bin/sh
+#   Copyright 2013 Vastech SA (PTY) LTD
+#
+#   Licensed under the Apache License, Version 2.0 (the "License");
+#   you may not use this file except in compliance with the License.
+#   You may obtain a copy of the License at
+#
+#       http://www.apache.org/licenses/LICENSE-2.0
+#
+#   Unless required by applicable law or agreed to in writing, software
+#   distributed under the License is distributed on an "AS IS" BASIS,
+#   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+#   See the License for the specific language governing permissions and
+#   limitations under the License.
+
+# generate.sh regenerates the Coding directories of the fixtures of
+# jerasurefile_test.go from their input.txt files, with encoder.c built
+# against the jerasure 1.2 sources at the root of the repository. Every
+# invocation below produces one row of jerasureFixtures.
+#
+# Files that are encoded at once are padded with uninitialised memory by
+# the encoder, which comes out as zero bytes in a fresh process, and
+# which EncodeJerasure writes as zero bytes.
+set -e
+
+cd "$(dirname "$0")"
+root=../..
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+
+gcc -O2 -fno-strict-aliasing -w -I"$root" -o "$tmp/encoder" encoder.c \
+	"$root/jerasure.c" "$root/galois.c" "$root/reed_sol.c" "$root/cauchy.c" "$root/liberation.c"
+
+# encode <technique> <k> <m> <w> <packetsize> <buffersize>
+encode() {
+	rm -rf "$1/Coding"
+	(cd "$1" && "$tmp/encoder" input.txt "$2" "$3" "$1" "$4" "$5" "$6")
+}
+
+encode cauchy_good 4 2 8 8 0
+encode cauchy_orig 4 2 8 8 1000
+encode reed_sol_van 3 2 8 0 768
+encode reed_sol_r6_op 4 2 8 0 150
+encode liberation 10 2 11 8 0
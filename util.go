@@ -1,5 +1,3 @@
-// +build linux
-
 //   Copyright 2013 Vastech SA (PTY) LTD
 //
 //   Licensed under the Apache License, Version 2.0 (the "License");
@@ -16,15 +14,11 @@
 
 package goerasure
 
-// #include "jerasure.h"
-import "C"
-
 import (
-	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"strings"
 	"unsafe"
 )
 
@@ -32,17 +26,51 @@ var (
 	sizeInt = int(unsafe.Sizeof(int(0)))
 )
 
-var noDataErr = errors.New("Source data is empty")
-var blocksUnequalErr = errors.New("Input block sizes do not match.")
+// PrintMatrix prints the contents of an r x c coding matrix over
+// GF(2^w), in the format of jerasure_print_matrix.
+func PrintMatrix(matrix []int, r, c, w int) {
+	fmt.Print(formatMatrix(matrix, r, c, w))
+}
 
-//intSliceToC converts a Go slice into a C int pointer array
-func intSliceToC(slice []int) *C.int {
-	return (*C.int)(unsafe.Pointer(&slice[0]))
+// formatMatrix formats an r x c matrix over GF(2^w) the way
+// jerasure_print_matrix prints it.
+func formatMatrix(matrix []int, r, c, w int) string {
+	// Every element is printed as wide as the largest element
+	fw := 10
+	if w != 32 {
+		fw = len(fmt.Sprint(1<<uint(w) - 1))
+	}
+
+	var b strings.Builder
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			if j != 0 {
+				b.WriteByte(' ')
+			}
+			fmt.Fprintf(&b, "%*d", fw, uint32(matrix[i*c+j]))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
 }
 
-//PrintMatrix prints the contents of a coding matrix.
-func PrintMatrix(matrix []int, r, c, w int) {
-	C.jerasure_print_matrix(intSliceToC(matrix), C.int(r), C.int(c), C.int(w))
+// formatBitmatrix formats an r x c bitmatrix the way
+// jerasure_print_bitmatrix prints it, with every w x w block set apart.
+func formatBitmatrix(bitmatrix []int, r, c, w int) string {
+	var b strings.Builder
+	for i := 0; i < r; i++ {
+		if i != 0 && i%w == 0 {
+			b.WriteByte('\n')
+		}
+		for j := 0; j < c; j++ {
+			if j != 0 && j%w == 0 {
+				b.WriteByte(' ')
+			}
+			fmt.Fprintf(&b, "%d", bitmatrix[i*c+j])
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
 }
 
 type LenReader interface {
@@ -50,130 +78,153 @@ type LenReader interface {
 	Len() int64
 }
 
-func newFileLenReader(f *os.File) LenReader {
-	return &fileLenReader{f}
-}
-
-type fileLenReader struct {
-	f *os.File
+// BlockWriter stores blocks of a stripe as their buffers are produced.
+type BlockWriter interface {
+	// Data writes the next buffer of each data block that is stored.
+	Data(buf [][]byte) error
+	// Coding writes the next buffer of each coding block that is stored.
+	Coding(buf [][]byte) error
+	// Commit flushes the blocks and replaces any existing blocks with
+	// them. It reports the first error of any earlier write.
+	Commit() error
+	// Close releases the blocks. Blocks that were not committed are
+	// discarded.
+	Close() error
 }
 
-func (this *fileLenReader) Len() int64 {
+// SyncMode selects when written blocks are flushed to stable storage.
+type SyncMode int
+
+const (
+	// SyncNone leaves flushing to the operating system.
+	SyncNone SyncMode = iota
+	// SyncOnCommit flushes every block, and the directory holding it,
+	// when the blocks are committed.
+	SyncOnCommit
+	// SyncEveryBuffer also flushes every block after each buffer.
+	SyncEveryBuffer
+)
 
-	fi, err := this.f.Stat()
-	if err != nil {
-		return 0
+// newShardBlockWriter returns a BlockWriter that stores the blocks with
+// the given ids of a stripe in store, under the given names of all
+// blocks of the stripe. All shards are created up front and each buffer
+// is written as soon as it is produced.
+//
+// The shards only replace the blocks on Commit, so a failed encode or
+// decode never leaves partial blocks behind.
+func newShardBlockWriter(store ShardStore, names []string, k int, ids []int, sync SyncMode) (BlockWriter, error) {
+	this := &shardBlockWriter{
+		names:  names,
+		k:      k,
+		sync:   sync,
+		shards: make(map[int]ShardWriter),
 	}
-	return fi.Size()
-}
-
-func (this *fileLenReader) Read(buf []byte) (n int, err error) {
-	return this.f.Read(buf)
+	for _, id := range ids {
+		shard, err := store.Create(names[id])
+		if err != nil {
+			this.Close()
+			return nil, err
+		}
+		this.shards[id] = shard
+	}
+	return this, nil
 }
 
-type BlockWriter interface {
-	Data(buf [][]byte)
-	Coding(buf [][]byte)
-	WriteData() error
-	WriteCoding() error
-	WriteErased(erasures []int) error
+type shardBlockWriter struct {
+	// names holds the names of the blocks, by block id.
+	names []string
+	k     int
+	sync  SyncMode
+	// shards holds the shards of the blocks that have not been
+	// committed, by block id.
+	shards map[int]ShardWriter
+	// err is the first write error, which is also reported by Commit.
+	err error
 }
 
-func newFileBlockWriter(filename string) BlockWriter {
-	return &fileBlockWriter{
-		base:   filepath.Base(filename),
-		ext:    filepath.Ext(filename),
-		dir:    filepath.Dir(filename),
-		data:   make(map[int][]byte),
-		coding: make(map[int][]byte),
+func (this *shardBlockWriter) Data(data [][]byte) error {
+	for id, shard := range this.shards {
+		if id < this.k {
+			this.write(shard, data[id])
+		}
 	}
+	return this.err
 }
 
-type fileBlockWriter struct {
-	base   string
-	ext    string
-	dir    string
-	data   map[int][]byte
-	coding map[int][]byte
-}
-
-func (this *fileBlockWriter) Data(data [][]byte) {
-	for i := range data {
-		this.data[i] = append(this.data[i], data[i]...)
+func (this *shardBlockWriter) Coding(coding [][]byte) error {
+	for id, shard := range this.shards {
+		if id >= this.k {
+			this.write(shard, coding[id-this.k])
+		}
 	}
+	return this.err
 }
 
-func (this *fileBlockWriter) Coding(coding [][]byte) {
-	for i := range coding {
-		this.coding[i] = append(this.coding[i], coding[i]...)
+// write writes a buffer to a shard, unless an earlier write failed.
+func (this *shardBlockWriter) write(shard ShardWriter, buf []byte) {
+	if this.err != nil {
+		return
+	}
+	if _, this.err = shard.Write(buf); this.err == nil && this.sync == SyncEveryBuffer {
+		this.err = shard.Sync()
 	}
 }
 
-func (this *fileBlockWriter) write(blocks map[int][]byte, dataOrCode string) error {
-	wd, err := os.Getwd()
-	if err != nil {
-		return err
+func (this *shardBlockWriter) Commit() error {
+	if this.err != nil {
+		return this.err
 	}
-	for i, buf := range blocks {
-		path := filepath.Join(wd, this.dir, fmt.Sprintf("%s_%s%d%s", this.base, dataOrCode, i, this.ext))
-		file, err := os.Create(path)
-		if err != nil {
-			return err
+	for id, shard := range this.shards {
+		if this.sync != SyncNone {
+			if err := shard.Sync(); err != nil {
+				return err
+			}
 		}
-		defer file.Close()
-		_, err = file.Write(buf)
-		if err != nil {
+		if err := shard.Commit(); err != nil {
 			return err
 		}
+		delete(this.shards, id)
 	}
 	return nil
 }
 
-func (this *fileBlockWriter) WriteData() error {
-	return this.write(this.data, "k")
+func (this *shardBlockWriter) Close() (err error) {
+	for id, shard := range this.shards {
+		if cerr := shard.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		delete(this.shards, id)
+	}
+	return err
 }
 
-func (this *fileBlockWriter) WriteCoding() error {
-	return this.write(this.coding, "m")
+// syncDir flushes a directory, so that renames in it are durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
 }
 
-func (this *fileBlockWriter) WriteErased(erasures []int) error {
-	var path string
-
-	wd, err := os.Getwd()
+// writeShard writes a small shard, such as a manifest, to store.
+func writeShard(store ShardStore, name string, buf []byte, sync SyncMode) error {
+	shard, err := store.Create(name)
 	if err != nil {
 		return err
 	}
+	defer shard.Close()
 
-	for _, erasure := range erasures {
-
-		if erasure == -1 {
-			break
-		}
-
-		if erasure < len(this.data) {
-			path = filepath.Join(wd, this.dir, fmt.Sprintf("%s_k%d%s", this.base, erasure, this.ext))
-		} else {
-			path = filepath.Join(wd, this.dir, fmt.Sprintf("%s_m%d%s", this.base, erasure, this.ext))
-		}
-		fmt.Printf("Path is: %s\n", path)
-		file, err := os.Create(path)
-		if err != nil {
-			return err
-		}
-		defer file.Close()
-
-		if erasure < len(this.data) {
-			_, err = file.Write(this.data[erasure])
-		} else {
-			_, err = file.Write(this.coding[erasure-len(this.data)])
-		}
-
-		if err != nil {
+	if _, err = shard.Write(buf); err != nil {
+		return err
+	}
+	if sync != SyncNone {
+		if err = shard.Sync(); err != nil {
 			return err
 		}
 	}
-	return nil
+	return shard.Commit()
 }
 
 func compareAndGetSizes(src []LenReader) (size int64, err error) {
@@ -191,7 +242,7 @@ func compareAndGetSizes(src []LenReader) (size int64, err error) {
 		//Make sure all blocks are the same length
 		if test_size != 0 {
 			if size != 0 && size != test_size {
-				err = blocksUnequalErr
+				err = ErrBlocksUnequal
 			}
 			size = test_size
 		}
@@ -208,3 +259,27 @@ func allocateBuffers(n int, buffersize int64) [][]byte {
 	}
 	return bufs
 }
+
+// roundUp rounds size up to the next multiple of multiple.
+func roundUp(size, multiple int64) int64 {
+	return (size + multiple - 1) / multiple * multiple
+}
+
+// readBuffer fills buf from r. When r runs out of data the rest of buf
+// is padded with zeros and eof is set; n reports the number of bytes
+// that were actually read.
+func readBuffer(r io.Reader, buf []byte) (n int, eof bool, err error) {
+	n, err = io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		eof, err = true, nil
+	}
+	zero(buf[n:])
+	return n, eof, err
+}
+
+// zero clears a buffer.
+func zero(buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+}
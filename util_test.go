@@ -0,0 +1,113 @@
+//   Copyright 2013 Vastech SA (PTY) LTD
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+
+package goerasure
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShardBlockWriter(t *testing.T) {
+	stripeName := filepath.Join(t.TempDir(), "stripe")
+	k := 2
+	names := []string{stripeName + "_k0", stripeName + "_k1", stripeName + "_m0"}
+
+	for _, sync := range []SyncMode{SyncNone, SyncOnCommit, SyncEveryBuffer} {
+		bw, err := newShardBlockWriter(NewDirStore(""), names, k, []int{1, 2}, sync)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 3; i++ {
+			if err = bw.Data([][]byte{{0, 0}, {1, byte(i)}}); err != nil {
+				t.Fatal(err)
+			}
+			if err = bw.Coding([][]byte{{2, byte(i)}}); err != nil {
+				t.Fatal(err)
+			}
+
+			// Every buffer is written before the blocks are committed
+			fi, err := os.Stat(stripeName + "_k1.tmp")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi.Size() != int64(2*(i+1)) {
+				t.Fatalf("expected %d bytes to be written, found %d", 2*(i+1), fi.Size())
+			}
+		}
+
+		if err = bw.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		if err = bw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = os.Stat(stripeName + "_k0"); !os.IsNotExist(err) {
+			t.Error("expected only the requested blocks to be written")
+		}
+		buf, err := os.ReadFile(stripeName + "_k1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf, []byte{1, 0, 1, 1, 1, 2}) {
+			t.Errorf("unexpected data block %v", buf)
+		}
+		buf, err = os.ReadFile(stripeName + "_m0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf, []byte{2, 0, 2, 1, 2, 2}) {
+			t.Errorf("unexpected coding block %v", buf)
+		}
+	}
+}
+
+func TestShardBlockWriterErrors(t *testing.T) {
+	stripeName := filepath.Join(t.TempDir(), "stripe")
+
+	bw, err := newShardBlockWriter(NewDirStore(""), []string{stripeName + "_k0"}, 1, []int{0}, SyncNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Writing to a closed file fails, and the failure sticks
+	bw.(*shardBlockWriter).shards[0].(*dirShardWriter).file.Close()
+	if err = bw.Data([][]byte{{1}}); err == nil {
+		t.Error("expected the write to fail")
+	}
+	if err = bw.Commit(); err == nil {
+		t.Error("expected the commit to report the write error")
+	}
+	bw.Close()
+
+	entries, err := os.ReadDir(filepath.Dir(stripeName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected uncommitted blocks to be removed, found %d files", len(entries))
+	}
+
+	// Directories are created as needed, but not below a file
+	if err = os.WriteFile(stripeName, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = newShardBlockWriter(NewDirStore(""), []string{filepath.Join(stripeName, "stripe_k0")}, 1, []int{0}, SyncNone); err == nil {
+		t.Error("expected creating a block below a file to fail")
+	}
+}